@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+type replaySummary struct {
+	Path        string `json:"path"`
+	MatchID     string `json:"matchId,omitempty"`
+	GameVersion string `json:"gameVersion,omitempty"`
+	GameMode    string `json:"gameMode,omitempty"`
+	GameLength  int64  `json:"gameLength,omitempty"` // milliseconds
+	Winner      string `json:"winner,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func runBatch(args []string) int {
+	c := newCLI("batch", "<dir>", formatText, formatJSON)
+	workers := c.flags.Int("j", runtime.NumCPU(), "number of replays parsed concurrently")
+	if code, ok := c.parse(args, 1); !ok {
+		return code
+	}
+
+	paths, err := findReplays(c.args[0])
+	if err != nil {
+		return c.fail(err)
+	}
+
+	summaries := make([]replaySummary, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range max(*workers, 1) {
+		wg.Go(func() {
+			for i := range jobs {
+				summaries[i] = summarize(paths[i], c.options())
+			}
+		})
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	code := exitOK
+	for _, s := range summaries {
+		if s.Error != "" {
+			code = exitFail
+		}
+	}
+
+	err = c.write(func(w io.Writer) error {
+		if c.format == formatJSON {
+			return writeJSON(w, summaries)
+		}
+		return writeSummaries(w, summaries)
+	})
+	if err != nil {
+		return c.fail(err)
+	}
+	return code
+}
+
+// findReplays returns the .rofl files under dir, in lexical order.
+func findReplays(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".rofl") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+func summarize(path string, opts []rofl.Option) replaySummary {
+	s := replaySummary{Path: path}
+	f, err := openMetadata(path, opts)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+
+	s.MatchID = f.PayloadHeader.MatchID()
+	s.GameVersion = f.GameVersion.String()
+	s.GameMode = f.Metadata.GameMode().String()
+	s.GameLength = int64(f.Metadata.GameLength)
+	// Arena and unfinished games have no winning team, that is not an error
+	if winner, err := f.Metadata.Winner(); err == nil && winner != rofl.TeamUnknown {
+		s.Winner = winner.String()
+	}
+	return s
+}
+
+func writeSummaries(w io.Writer, summaries []replaySummary) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tMATCH\tVERSION\tMODE\tLENGTH\tWINNER")
+	for _, s := range summaries {
+		if s.Error != "" {
+			fmt.Fprintf(tw, "%s\terror: %s\t\t\t\t\n", s.Path, s.Error)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Path, s.MatchID, s.GameVersion, s.GameMode, (time.Duration(s.GameLength) * time.Millisecond).Round(time.Second), s.Winner)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// cli holds the flags every command shares.
+type cli struct {
+	name    string
+	flags   *flag.FlagSet
+	format  string
+	output  string
+	verbose bool
+	formats []string
+	// args are the arguments left once the flags are parsed.
+	args []string
+}
+
+// newCLI returns the flag set of the command called name, accepting the given output formats,
+// the first one being the default.
+func newCLI(name, args string, formats ...string) *cli {
+	c := &cli{name: name, flags: flag.NewFlagSet(name, flag.ContinueOnError), formats: formats}
+	c.flags.StringVar(&c.format, "format", formats[0], fmt.Sprintf("output format %v", formats))
+	c.flags.StringVar(&c.output, "o", "", "write the output to this file instead of stdout")
+	c.flags.BoolVar(&c.verbose, "v", false, "log the parsing stages to stderr")
+	c.flags.Usage = func() {
+		fmt.Fprintf(c.flags.Output(), "usage: mdr %s [flags] %s\n", name, args)
+		c.flags.PrintDefaults()
+	}
+	return c
+}
+
+// parse parses the flags, which may come before or after the arguments, and checks
+// that at least minArgs arguments are given. ok is false when the command must stop,
+// with the given exit code.
+func (c *cli) parse(args []string, minArgs int) (code int, ok bool) {
+	for {
+		if err := c.flags.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return exitOK, false
+			}
+			return exitUsage, false
+		}
+		// Parse stops at the first argument, resume after it. "--" ends the flags for good.
+		rest := c.flags.Args()
+		if len(rest) == 0 || len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			c.args = append(c.args, rest...)
+			break
+		}
+		c.args = append(c.args, rest[0])
+		args = rest[1:]
+	}
+	if !slices.Contains(c.formats, c.format) {
+		fmt.Fprintf(os.Stderr, "mdr %s: unsupported format %q, want one of %v\n", c.name, c.format, c.formats)
+		return exitUsage, false
+	}
+	if len(c.args) < minArgs {
+		c.flags.Usage()
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// options returns the options to parse replays with.
+func (c *cli) options() []rofl.Option {
+	if !c.verbose {
+		return nil
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return []rofl.Option{rofl.WithLogger(logger)}
+}
+
+// write opens the output, hands it to fn and closes it.
+func (c *cli) write(fn func(w io.Writer) error) error {
+	if c.output == "" {
+		return fn(os.Stdout)
+	}
+
+	f, err := os.Create(c.output)
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fail reports err and returns the failure exit code.
+func (c *cli) fail(err error) int {
+	fmt.Fprintf(os.Stderr, "mdr %s: %v\n", c.name, err)
+	return exitFail
+}
+
+// openMetadata parses the header and metadata of the replay at path without reading
+// the rest of the file. Chunks and keyframes can not be read from the returned file.
+func openMetadata(path string, opts []rofl.Option) (*rofl.RoflFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return rofl.NewReader(file, info.Size(), append(slices.Clone(opts), rofl.WithName(path))...)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
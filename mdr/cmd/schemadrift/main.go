@@ -0,0 +1,104 @@
+// Command schemadrift compares the participant stats of two sets of replays, typically
+// the replays of the previous patch and of the new one:
+//
+//	go run ./cmd/schemadrift old/ new/
+//
+// It reports the stat keys added and removed, the keys whose JSON type changed
+// (e.g. a number now also stored as text) and the keys of the new replays that the
+// StatsJSON struct does not decode, either because it has no field for them or because
+// the field type does not accept the values found. Those are the keys to regenerate
+// StatsJSON for, see cmd/schemagen.
+//
+// The exit status is 1 when anything drifted, so it can gate a CI job.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"text/tabwriter"
+
+	"github.com/ZiedYousfi/analolzer/mdr/internal/schema"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("schemadrift: ")
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: schemadrift OLD NEW")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	older, n, err := schema.Load(flag.Args()[:1])
+	if err != nil {
+		log.Fatalf("%s: %v", flag.Arg(0), err)
+	}
+	newer, m, err := schema.Load(flag.Args()[1:])
+	if err != nil {
+		log.Fatalf("%s: %v", flag.Arg(1), err)
+	}
+	fmt.Printf("%d replays in %s, %d replays in %s\n", n, flag.Arg(0), m, flag.Arg(1))
+
+	drift := schema.Diff(older, newer)
+	uncovered := uncoveredKeys(newer, schema.StructFields(reflect.TypeFor[rofl.StatsJSON]()))
+
+	var added, removed, changed []row
+	for _, key := range drift.Added {
+		added = append(added, row{key, newer[key].String()})
+	}
+	for _, key := range drift.Removed {
+		removed = append(removed, row{key, older[key].String()})
+	}
+	for _, c := range drift.Changed {
+		changed = append(changed, row{c.Key, c.Old.String() + " -> " + c.New.String()})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	section(w, "added", added)
+	section(w, "removed", removed)
+	section(w, "type changed", changed)
+	section(w, "not covered by StatsJSON", uncovered)
+	w.Flush()
+
+	if !drift.Empty() || len(uncovered) > 0 {
+		os.Exit(1)
+	}
+}
+
+// row is a stat key and what is reported about it.
+type row struct {
+	key    string
+	detail string
+}
+
+// uncoveredKeys lists the keys of s that fields does not decode, sorted.
+func uncoveredKeys(s schema.Schema, fields map[string]schema.Field) []row {
+	var out []row
+	for _, key := range s.Keys() {
+		f, ok := fields[key]
+		switch {
+		case !ok:
+			out = append(out, row{key, "no field, " + s[key].String()})
+		case !schema.Accepts(f.GoType, s[key]):
+			out = append(out, row{key, fmt.Sprintf("%s %s, found %s", f.Name, f.GoType, s[key])})
+		}
+	}
+	return out
+}
+
+func section(w io.Writer, title string, rows []row) {
+	fmt.Fprintf(w, "\n%s (%d):\n", title, len(rows))
+	for _, r := range rows {
+		fmt.Fprintf(w, "  %s\t%s\n", r.key, r.detail)
+	}
+}
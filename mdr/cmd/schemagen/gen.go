@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/ZiedYousfi/analolzer/mdr/internal/schema"
+)
+
+const header = `// Code generated by schemagen from replay metadata. DO NOT EDIT.
+// To regenerate it, see the go:generate directive in metadata.go.
+
+`
+
+// union is a generated type for a stat holding values of several JSON types.
+type union struct {
+	Name    string
+	Integer bool
+	Double  bool
+	String  bool
+}
+
+type field struct {
+	schema.Field
+	union *union
+}
+
+// generate returns the source of the struct decoding every key of existing and observed.
+// Fields of existing keep their name, and their type unless observed widens it.
+func generate(pkg, typeName string, existing map[string]schema.Field, observed schema.Schema) ([]byte, error) {
+	merged := make(schema.Schema, len(existing)+len(observed))
+	for key, f := range existing {
+		merged[key] = schema.GoTypeSchema(f.GoType)
+	}
+	merged.Merge(observed)
+
+	taken := make(map[string]bool, len(merged))
+	for _, f := range existing {
+		taken[f.Name] = true
+	}
+
+	var fields []field
+	for _, key := range merged.Keys() {
+		t := merged[key]
+
+		f := field{Field: existing[key]}
+		if f.Name == "" {
+			f = field{Field: schema.Field{Key: key, Name: uniqueName(goName(key), taken)}}
+		} else if schema.GoTypeSchema(f.GoType) == t {
+			// Unchanged, keep the type as written, including the name of its union
+			if t == schema.TypeInteger|schema.TypeString {
+				f.union = &union{Name: strings.TrimPrefix(f.GoType, "*"), Integer: true, String: true}
+			}
+			fields = append(fields, f)
+			continue
+		}
+
+		goType, u := goTypeOf(t, f.Name)
+		if f.GoType != "" {
+			log.Printf("%s changes from %s to %s", f.Name, f.GoType, goType)
+		}
+		f.GoType, f.union = goType, u
+		fields = append(fields, f)
+	}
+
+	slices.SortFunc(fields, func(a, b field) int {
+		return strings.Compare(sortKey(a.Name), sortKey(b.Name))
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	fmt.Fprintf(&buf, "package %s\n\nimport \"encoding/json\"\n\n", pkg)
+
+	fmt.Fprintf(&buf, "// %s holds the stats of one participant, as stored in the statsJson field of the metadata.\n", typeName)
+	fmt.Fprintf(&buf, "// Stats missing from it because they were added by a newer patch can be read with Stat.\n")
+	fmt.Fprintf(&buf, "type %s struct {\n", typeName)
+	for _, f := range fields {
+		fmt.Fprintf(&buf, "%s %s `json:%q`\n", f.Name, f.GoType, f.Key)
+	}
+	buf.WriteString("\n// raw keeps every key of the participant as found in the replay, see Stat.\n")
+	buf.WriteString("raw map[string]json.RawMessage\n}\n")
+
+	for _, f := range fields {
+		if f.union != nil {
+			writeUnion(&buf, f.union)
+		}
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// goTypeOf maps the types observed for a stat to the Go type decoding them.
+// Nulls are ignored, a missing stat and a null one both decode to the zero value.
+func goTypeOf(t schema.Type, name string) (string, *union) {
+	switch t &^ schema.TypeNull {
+	case schema.TypeInteger:
+		return "FlexInt64", nil
+	case schema.TypeNumber, schema.TypeInteger | schema.TypeNumber:
+		return "FlexFloat64", nil
+	case schema.TypeString:
+		return "string", nil
+	case schema.TypeBool:
+		return "bool", nil
+	}
+
+	if t&(schema.TypeBool|schema.TypeString) == schema.TypeString {
+		u := &union{
+			Name:    name,
+			Integer: t&schema.TypeInteger != 0,
+			Double:  t&schema.TypeNumber != 0,
+			String:  true,
+		}
+		return "*" + name, u
+	}
+	return "json.RawMessage", nil
+}
+
+func writeUnion(buf *bytes.Buffer, u *union) {
+	ptr := func(ok bool, field string) string {
+		if ok {
+			return "&x." + field
+		}
+		return "nil"
+	}
+	val := func(ok bool, field string) string {
+		if ok {
+			return "x." + field
+		}
+		return "nil"
+	}
+
+	fmt.Fprintf(buf, "\ntype %s struct {\n", u.Name)
+	if u.Integer {
+		buf.WriteString("Integer *int64\n")
+	}
+	if u.Double {
+		buf.WriteString("Double *float64\n")
+	}
+	buf.WriteString("String *string\n}\n")
+
+	fmt.Fprintf(buf, "\nfunc (x *%s) UnmarshalJSON(data []byte) error {\n", u.Name)
+	fmt.Fprintf(buf, "_, err := unmarshalUnion(data, %s, %s, nil, &x.String, false, nil, false, nil, false, nil, false, nil, false)\n",
+		ptr(u.Integer, "Integer"), ptr(u.Double, "Double"))
+	buf.WriteString("return err\n}\n")
+
+	fmt.Fprintf(buf, "\nfunc (x *%s) MarshalJSON() ([]byte, error) {\n", u.Name)
+	fmt.Fprintf(buf, "return marshalUnion(%s, %s, nil, x.String, false, nil, false, nil, false, nil, false, nil, false)\n}\n",
+		val(u.Integer, "Integer"), val(u.Double, "Double"))
+}
+
+// initialisms are kept upper case in field names.
+var initialisms = map[string]bool{"ID": true, "API": true, "JSON": true, "URL": true}
+
+// goName turns a stat key into a field name: "CHAMPIONS_KILLED" becomes ChampionsKilled,
+// "Event_ARAM_Docks" becomes EventARAMDocks and keys starting with a digit get a "The" prefix.
+func goName(key string) string {
+	// Upper case words are only rewritten in upper case keys, mixed case keys are already readable
+	mixed := strings.ToUpper(key) != key
+
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if mixed {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+			continue
+		}
+		for _, word := range splitDigits(part) {
+			if initialisms[word] {
+				b.WriteString(word)
+			} else {
+				b.WriteString(word[:1] + strings.ToLower(word[1:]))
+			}
+		}
+	}
+
+	name := b.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "The" + name
+	}
+	return name
+}
+
+// splitDigits splits an upper case word on letter/digit boundaries, "PERK1" gives PERK and 1.
+func splitDigits(s string) []string {
+	var words []string
+	start := 0
+	for i := 1; i < len(s); i++ {
+		if unicode.IsDigit(rune(s[i])) != unicode.IsDigit(rune(s[i-1])) {
+			words = append(words, s[start:i])
+			start = i
+		}
+	}
+	return append(words, s[start:])
+}
+
+func uniqueName(name string, taken map[string]bool) string {
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	taken[candidate] = true
+	return candidate
+}
+
+// sortKey orders fields alphabetically, ignoring case and the prefix of names starting with a digit.
+func sortKey(name string) string {
+	if rest, ok := strings.CutPrefix(name, "The"); ok && rest != "" && unicode.IsDigit(rune(rest[0])) {
+		name = rest
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,82 @@
+// Command schemagen generates the StatsJSON struct of the rofl package from real replays.
+//
+// It reads the participant stats of every replay given, files or directories walked for
+// .rofl files, merges the types observed for each key and writes the struct along with
+// the union types it needs. Field names already present in the -existing file are kept,
+// so regenerating only adds fields or widens their types.
+//
+// It is run by go generate from the rofl package:
+//
+//	MDR_REPLAYS=/path/to/replays go generate ./rofl
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+
+	"github.com/ZiedYousfi/analolzer/mdr/internal/schema"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("schemagen: ")
+
+	existing := flag.String("existing", "", "Go file holding the current struct, whose field names are kept")
+	output := flag.String("o", "", "output file (default stdout)")
+	typeName := flag.String("type", "StatsJSON", "name of the generated struct")
+	pkg := flag.String("package", "rofl", "package of the generated file")
+	flag.Parse()
+
+	var paths []string
+	for _, arg := range flag.Args() {
+		// go generate expands an unset variable to an empty argument
+		if arg != "" {
+			paths = append(paths, arg)
+		}
+	}
+
+	fields := map[string]schema.Field{}
+	if *existing != "" {
+		var err error
+		fields, err = schema.ReadStruct(*existing, *typeName)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Fatal(err)
+		}
+	}
+
+	observed, n, err := schema.Load(paths)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if n == 0 && len(fields) == 0 {
+		log.Fatal("no replay and no existing struct to generate from")
+	}
+
+	src, err := generate(*pkg, *typeName, fields, observed)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "schemagen: %d replays, %d fields written to %s\n", n, countFields(fields, observed), *output)
+}
+
+func countFields(fields map[string]schema.Field, observed schema.Schema) int {
+	n := len(fields)
+	for key := range observed {
+		if _, ok := fields[key]; !ok {
+			n++
+		}
+	}
+	return n
+}
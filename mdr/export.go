@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl/export"
+)
+
+const (
+	formatCSV     = "csv"
+	formatTSV     = "tsv"
+	formatParquet = "parquet"
+)
+
+func runExport(args []string) int {
+	c := newCLI("export", "<file or dir>...", formatCSV, formatTSV, formatParquet)
+	columns := c.flags.String("columns", "", "comma-separated columns to export, game columns and stat keys (default all, unknown stat keys included)")
+	maxRows := c.flags.Int64("max-rows", 0, "rows per Parquet file before the next part is started (default 1048576)")
+	if code, ok := c.parse(args, 1); !ok {
+		return code
+	}
+	if c.format == formatParquet && c.output == "" {
+		fmt.Fprintln(os.Stderr, "mdr export: -o must name the output directory of the Parquet dataset")
+		return exitUsage
+	}
+
+	var paths []string
+	for _, arg := range c.args {
+		fi, err := os.Stat(arg)
+		if err != nil {
+			return c.fail(err)
+		}
+		if !fi.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+		found, err := findReplays(arg)
+		if err != nil {
+			return c.fail(err)
+		}
+		paths = append(paths, found...)
+	}
+
+	var opts []export.Option
+	if *columns != "" {
+		names := strings.Split(*columns, ",")
+		for i, name := range names {
+			if names[i] = strings.TrimSpace(name); names[i] == "" {
+				fmt.Fprintf(os.Stderr, "mdr export: -columns %q has an empty column name\n", *columns)
+				return exitUsage
+			}
+		}
+		opts = append(opts, export.WithColumns(names...))
+	} else if c.format != formatParquet {
+		// The CSV header is written before the first replay, so a first pass collects the
+		// stats newer than StatsJSON. Parquet parts extend their schema as they go.
+		if keys := unknownStatKeys(paths, c.options()); len(keys) > 0 {
+			opts = append(opts, export.WithColumns(slices.Concat(export.DefaultColumns(), keys)...))
+		}
+	}
+	if c.format == formatTSV {
+		opts = append(opts, export.WithComma('\t'))
+	}
+	if *maxRows > 0 {
+		opts = append(opts, export.WithMaxRowsPerFile(*maxRows))
+	}
+	if c.format == formatParquet {
+		return exportParquet(c, paths, opts)
+	}
+
+	code := exitOK
+	err := c.write(func(w io.Writer) error {
+		ew := export.NewWriter(w, opts...)
+		// Replays are parsed one at a time so that a directory of any size fits in memory
+		for _, path := range paths {
+			f, err := openMetadata(path, c.options())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "mdr export: %v\n", err)
+				code = exitFail
+				continue
+			}
+			if err := ew.Write(f); err != nil {
+				return err
+			}
+		}
+		return ew.Flush()
+	})
+	if err != nil {
+		return c.fail(err)
+	}
+	return code
+}
+
+// exportParquet writes the replays as a Parquet dataset in the -o directory.
+func exportParquet(c *cli, paths []string, opts []export.Option) int {
+	code := exitOK
+	pw := export.NewParquetWriter(c.output, opts...)
+	for _, path := range paths {
+		f, err := openMetadata(path, c.options())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "mdr export: %v\n", err)
+			code = exitFail
+			continue
+		}
+		if err := pw.Write(f); err != nil {
+			pw.Close()
+			return c.fail(err)
+		}
+	}
+	if err := pw.Close(); err != nil {
+		return c.fail(err)
+	}
+	return code
+}
+
+// unknownStatKeys returns the stat keys StatsJSON has no field for that the replays hold,
+// sorted. Replays that fail to open are skipped, the export reports them.
+func unknownStatKeys(paths []string, opts []rofl.Option) []string {
+	var keys []string
+	for _, path := range paths {
+		f, err := openMetadata(path, opts)
+		if err != nil {
+			continue
+		}
+		for i := range f.Metadata.StatsJSON {
+			keys = append(keys, f.Metadata.StatsJSON[i].UnknownKeys()...)
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl/index"
+)
+
+type indexSummary struct {
+	Indexed   int      `json:"indexed"`
+	Unchanged int      `json:"unchanged"`
+	Removed   int      `json:"removed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+func runIndex(args []string) int {
+	c := newCLI("index", "<dir>...", formatText, formatJSON)
+	db := c.flags.String("db", "replays.sqlite", "SQLite database to update")
+	workers := c.flags.Int("j", runtime.NumCPU(), "number of replays parsed concurrently")
+	if code, ok := c.parse(args, 1); !ok {
+		return code
+	}
+
+	idx, err := index.Open(*db, index.WithWorkers(*workers), index.WithParseOptions(c.options()...))
+	if err != nil {
+		return c.fail(err)
+	}
+	defer idx.Close()
+
+	// Interrupting keeps what was indexed so far, every replay is written in its own transaction
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	res, err := idx.Index(ctx, c.args...)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	summary := indexSummary{Indexed: res.Indexed, Unchanged: res.Unchanged, Removed: res.Removed}
+	for _, err := range res.Errors {
+		summary.Errors = append(summary.Errors, err.Error())
+	}
+	err = c.write(func(w io.Writer) error {
+		if c.format == formatJSON {
+			return writeJSON(w, summary)
+		}
+		for _, e := range summary.Errors {
+			fmt.Fprintf(w, "error: %s\n", e)
+		}
+		_, err := fmt.Fprintf(w, "%d indexed, %d unchanged, %d removed, %d failed\n",
+			summary.Indexed, summary.Unchanged, summary.Removed, len(summary.Errors))
+		return err
+	})
+	if err != nil {
+		return c.fail(err)
+	}
+	if len(res.Errors) > 0 {
+		return exitFail
+	}
+	return exitOK
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+type sectionInfo struct {
+	Offset uint64 `json:"offset"`
+	Length uint64 `json:"length"`
+}
+
+type replayInfo struct {
+	Path        string `json:"path"`
+	Size        int    `json:"size"`
+	Layout      string `json:"layout"`
+	GameVersion string `json:"gameVersion"`
+	MatchID     string `json:"matchId,omitempty"`
+	GameID      uint64 `json:"gameId,omitempty"`
+	// GameLength is in milliseconds, as in the metadata.
+	GameLength    int64       `json:"gameLength"`
+	GameMode      string      `json:"gameMode"`
+	Participants  int         `json:"participants"`
+	Encrypted     bool        `json:"encrypted"`
+	Chunks        int         `json:"chunks"`
+	Keyframes     int         `json:"keyframes"`
+	Metadata      sectionInfo `json:"metadata"`
+	PayloadHeader sectionInfo `json:"payloadHeader"`
+	PayloadOffset uint32      `json:"payloadOffset"`
+}
+
+func runInfo(args []string) int {
+	c := newCLI("info", "<file>", formatText, formatJSON)
+	if code, ok := c.parse(args, 1); !ok {
+		return code
+	}
+
+	f, err := rofl.OpenRoflFile(c.args[0], c.options()...)
+	if err != nil {
+		return c.fail(err)
+	}
+	info := newReplayInfo(f)
+
+	err = c.write(func(w io.Writer) error {
+		if c.format == formatJSON {
+			return writeJSON(w, info)
+		}
+		return writeInfo(w, info)
+	})
+	if err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
+
+func newReplayInfo(f *rofl.RoflFile) replayInfo {
+	info := replayInfo{
+		Path:         f.Path,
+		Size:         len(f.FileBuffer),
+		Layout:       f.Header.Layout.String(),
+		GameVersion:  f.GameVersion.String(),
+		MatchID:      f.PayloadHeader.MatchID(),
+		GameID:       f.PayloadHeader.GameID,
+		GameLength:   int64(f.Metadata.GameLength),
+		GameMode:     f.Metadata.GameMode().String(),
+		Participants: len(f.Metadata.StatsJSON),
+		Encrypted:    f.PayloadHeader.EncryptionKey != "",
+		Chunks:       int(f.PayloadHeader.ChunkCount),
+		Keyframes:    int(f.PayloadHeader.KeyframeCount),
+		Metadata: sectionInfo{
+			Offset: f.MetadataOffset,
+			Length: uint64(f.Header.MetadataLength),
+		},
+		PayloadHeader: sectionInfo{
+			Offset: uint64(f.Header.PayloadHeaderOffset),
+			Length: uint64(f.Header.PayloadHeaderLength),
+		},
+		PayloadOffset: f.Header.PayloadOffset,
+	}
+
+	// The segment index is the actual count, the payload header is all there is when it can not be built
+	if idx, err := f.SegmentIndex(); err == nil {
+		info.Chunks = len(idx.Chunks)
+		info.Keyframes = len(idx.Keyframes)
+	}
+	return info
+}
+
+func writeInfo(w io.Writer, info replayInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	rows := []struct {
+		key   string
+		value any
+	}{
+		{"path", info.Path},
+		{"size", info.Size},
+		{"layout", info.Layout},
+		{"game version", info.GameVersion},
+		{"match ID", info.MatchID},
+		{"game length", time.Duration(info.GameLength) * time.Millisecond},
+		{"game mode", info.GameMode},
+		{"participants", info.Participants},
+		{"encrypted", info.Encrypted},
+		{"chunks", info.Chunks},
+		{"keyframes", info.Keyframes},
+		{"metadata", fmt.Sprintf("%d bytes at %d", info.Metadata.Length, info.Metadata.Offset)},
+		{"payload header", fmt.Sprintf("%d bytes at %d", info.PayloadHeader.Length, info.PayloadHeader.Offset)},
+		{"payload offset", info.PayloadOffset},
+	}
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s:\t%v\n", r.key, r.value)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,213 @@
+// Package events extracts typed game events from the packets of a replay.
+//
+// Packet IDs change from one patch to the next, so the extractor is driven by a Table
+// telling which packet ID carries which kind of event, built directly or with TableFrom.
+// The payload layout of each kind is documented on its event type, every value is
+// little endian.
+//
+// Neither the layouts nor any table have been checked against a real replay yet: they
+// are inferred, and no table ships with this module. The package stays internal until
+// a table and its layouts are verified for a patch.
+package events
+
+import (
+	"time"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+// Kind identifies the type of an event, or of the packet carrying it.
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	// KindHeroSpawn is not an event by itself, it is the packet binding a hero net ID to a participant.
+	KindHeroSpawn
+	KindChampionKill
+	KindBuildingKill
+	KindEpicMonsterKill
+	KindItemPurchased
+	KindLevelUp
+	KindSkillLevelUp
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindHeroSpawn:
+		return "HeroSpawn"
+	case KindChampionKill:
+		return "ChampionKill"
+	case KindBuildingKill:
+		return "BuildingKill"
+	case KindEpicMonsterKill:
+		return "EpicMonsterKill"
+	case KindItemPurchased:
+		return "ItemPurchased"
+	case KindLevelUp:
+		return "LevelUp"
+	case KindSkillLevelUp:
+		return "SkillLevelUp"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is implemented by every typed event.
+type Event interface {
+	Kind() Kind
+	// Time is the game time at which the event happened.
+	Time() time.Duration
+}
+
+// Participant is an entity of the game resolved against the metadata.
+// Stats is nil when the net ID does not belong to a known participant
+// (minions, monsters, turrets or heroes spawned before the mapping was known).
+type Participant struct {
+	NetID uint32
+	Stats *rofl.StatsJSON
+}
+
+// PUUID returns the PUUID of the participant, or an empty string if it is unknown.
+func (p Participant) PUUID() string {
+	if p.Stats == nil {
+		return ""
+	}
+	return p.Stats.Puuid
+}
+
+// Known reports whether the entity was matched to a participant of the metadata.
+func (p Participant) Known() bool {
+	return p.Stats != nil
+}
+
+// ChampionKill is carried by a packet whose net ID is the victim.
+// Payload: killer net ID (uint32), assist count (uint8), assister net IDs (uint32 each).
+type ChampionKill struct {
+	At        time.Duration
+	Killer    Participant
+	Victim    Participant
+	Assisters []Participant
+}
+
+func (e ChampionKill) Kind() Kind          { return KindChampionKill }
+func (e ChampionKill) Time() time.Duration { return e.At }
+
+// BuildingKill is carried by a packet whose net ID is the building.
+// Payload: killer net ID (uint32).
+type BuildingKill struct {
+	At            time.Duration
+	Killer        Participant
+	BuildingNetID uint32
+}
+
+func (e BuildingKill) Kind() Kind          { return KindBuildingKill }
+func (e BuildingKill) Time() time.Duration { return e.At }
+
+// Monster is the epic monster of an EpicMonsterKill.
+type Monster uint8
+
+const (
+	MonsterUnknown Monster = iota
+	MonsterDragon
+	MonsterElderDragon
+	MonsterBaron
+	MonsterRiftHerald
+	MonsterVoidgrub
+	MonsterAtakhan
+)
+
+func (m Monster) String() string {
+	switch m {
+	case MonsterDragon:
+		return "Dragon"
+	case MonsterElderDragon:
+		return "ElderDragon"
+	case MonsterBaron:
+		return "Baron"
+	case MonsterRiftHerald:
+		return "RiftHerald"
+	case MonsterVoidgrub:
+		return "Voidgrub"
+	case MonsterAtakhan:
+		return "Atakhan"
+	default:
+		return "Unknown"
+	}
+}
+
+// EpicMonsterKill is carried by a packet whose net ID is the monster.
+// Payload: killer net ID (uint32), monster (uint8).
+type EpicMonsterKill struct {
+	At           time.Duration
+	Killer       Participant
+	Monster      Monster
+	MonsterNetID uint32
+}
+
+func (e EpicMonsterKill) Kind() Kind          { return KindEpicMonsterKill }
+func (e EpicMonsterKill) Time() time.Duration { return e.At }
+
+// ItemPurchased is carried by a packet whose net ID is the buyer.
+// Payload: item ID (uint32), inventory slot (uint8).
+type ItemPurchased struct {
+	At          time.Duration
+	Participant Participant
+	ItemID      uint32
+	Slot        uint8
+}
+
+func (e ItemPurchased) Kind() Kind          { return KindItemPurchased }
+func (e ItemPurchased) Time() time.Duration { return e.At }
+
+// LevelUp is carried by a packet whose net ID is the hero.
+// Payload: new level (uint8).
+type LevelUp struct {
+	At          time.Duration
+	Participant Participant
+	Level       uint8
+}
+
+func (e LevelUp) Kind() Kind          { return KindLevelUp }
+func (e LevelUp) Time() time.Duration { return e.At }
+
+// SkillLevelUp is a point put in an ability, carried by a packet whose net ID is the hero.
+// Payload: ability slot (uint8, 0 to 3 for Q, W, E and R), new ability level (uint8).
+type SkillLevelUp struct {
+	At          time.Duration
+	Participant Participant
+	Slot        uint8
+	Level       uint8
+}
+
+func (e SkillLevelUp) Kind() Kind          { return KindSkillLevelUp }
+func (e SkillLevelUp) Time() time.Duration { return e.At }
+
+// Minute groups the events that happened during one minute of game time.
+type Minute struct {
+	Index  int
+	Events []Event
+}
+
+// Timeline buckets events per minute of game time. Every minute up to the last event
+// is present, minutes without events have an empty Events slice.
+func Timeline(events []Event) []Minute {
+	if len(events) == 0 {
+		return nil
+	}
+
+	last := 0
+	for _, e := range events {
+		last = max(last, int(e.Time()/time.Minute))
+	}
+
+	minutes := make([]Minute, last+1)
+	for i := range minutes {
+		minutes[i].Index = i
+	}
+	for _, e := range events {
+		m := int(e.Time() / time.Minute)
+		minutes[m].Events = append(minutes[m].Events, e)
+	}
+
+	return minutes
+}
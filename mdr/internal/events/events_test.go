@@ -0,0 +1,48 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeline(t *testing.T) {
+	at := func(d time.Duration) Event { return LevelUp{At: d} }
+	early, late, last := at(30*time.Second), at(59*time.Second), at(2*time.Minute+10*time.Second)
+
+	tests := []struct {
+		name   string
+		events []Event
+		want   []Minute
+	}{
+		{name: "no event"},
+		{name: "first minute", events: []Event{early}, want: []Minute{{Index: 0, Events: []Event{early}}}},
+		{
+			name:   "minutes without events are kept",
+			events: []Event{early, late, last},
+			want: []Minute{
+				{Index: 0, Events: []Event{early, late}},
+				{Index: 1},
+				{Index: 2, Events: []Event{last}},
+			},
+		},
+		{
+			name:   "order within a minute is kept",
+			events: []Event{last, late, early},
+			want: []Minute{
+				{Index: 0, Events: []Event{late, early}},
+				{Index: 1},
+				{Index: 2, Events: []Event{last}},
+			},
+		},
+		{name: "on the minute", events: []Event{at(time.Minute)}, want: []Minute{{Index: 0}, {Index: 1, Events: []Event{at(time.Minute)}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Timeline(tt.events)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Timeline() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package events
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ZiedYousfi/analolzer/mdr/internal/heroes"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl/packets"
+)
+
+// Table maps the packet IDs of a patch to the kind of event they carry.
+type Table map[uint16]Kind
+
+// TableFrom builds an event table out of a packet table, matching packet names with Kind names.
+// Packets whose name is not an event kind are left out.
+func TableFrom(pt packets.Table) Table {
+	kinds := make(map[string]Kind)
+	for k := KindHeroSpawn; k <= KindSkillLevelUp; k++ {
+		kinds[k.String()] = k
+	}
+
+	t := make(Table)
+	for id, name := range pt {
+		if k, ok := kinds[name]; ok {
+			t[id] = k
+		}
+	}
+	return t
+}
+
+// PacketTable returns the table as a packets.Table so packet names can be filled while iterating.
+func (t Table) PacketTable() packets.Table {
+	pt := make(packets.Table, len(t))
+	for id, k := range t {
+		pt[id] = k.String()
+	}
+	return pt
+}
+
+// Extractor turns packets into events, resolving net IDs against the participants of the metadata.
+// Packets have to be fed in game order, hero spawns come before the events of that hero.
+type Extractor struct {
+	table  Table
+	stats  []rofl.StatsJSON
+	heroes map[uint32]*rofl.StatsJSON
+}
+
+// NewExtractor returns an extractor for a replay with the given metadata and packet table.
+func NewExtractor(metadata rofl.Metadata, table Table) *Extractor {
+	return &Extractor{
+		table:  table,
+		stats:  metadata.StatsJSON,
+		heroes: make(map[uint32]*rofl.StatsJSON),
+	}
+}
+
+// Participant resolves a net ID to a participant.
+func (x *Extractor) Participant(netID uint32) Participant {
+	return Participant{NetID: netID, Stats: x.heroes[netID]}
+}
+
+// Feed decodes one packet. ok is false when the packet does not carry an event,
+// either because its ID is not in the table or because it is a hero spawn.
+func (x *Extractor) Feed(p packets.Packet) (e Event, ok bool, err error) {
+	kind, found := x.table[p.ID]
+	if !found {
+		return nil, false, nil
+	}
+
+	if kind == KindHeroSpawn {
+		h, err := heroes.DecodeSpawn(p)
+		if err != nil {
+			return nil, false, err
+		}
+		x.bindHero(h)
+		return nil, false, nil
+	}
+
+	r := payloadReader{b: p.Payload}
+
+	switch kind {
+	case KindChampionKill:
+		e = x.decodeChampionKill(p, &r)
+	case KindBuildingKill:
+		e = BuildingKill{At: p.Time, Killer: x.Participant(r.u32()), BuildingNetID: p.NetID}
+	case KindEpicMonsterKill:
+		e = EpicMonsterKill{At: p.Time, Killer: x.Participant(r.u32()), Monster: Monster(r.u8()), MonsterNetID: p.NetID}
+	case KindItemPurchased:
+		e = ItemPurchased{At: p.Time, Participant: x.Participant(p.NetID), ItemID: r.u32(), Slot: r.u8()}
+	case KindLevelUp:
+		e = LevelUp{At: p.Time, Participant: x.Participant(p.NetID), Level: r.u8()}
+	case KindSkillLevelUp:
+		e = SkillLevelUp{At: p.Time, Participant: x.Participant(p.NetID), Slot: r.u8(), Level: r.u8()}
+	default:
+		return nil, false, nil
+	}
+
+	if err := r.err(p, kind); err != nil {
+		return nil, false, err
+	}
+	return e, true, nil
+}
+
+// bindHero binds the net ID of a hero spawn to a participant. The PUUID is preferred,
+// the participant ID is used when the PUUID is empty or unknown.
+func (x *Extractor) bindHero(h heroes.Spawn) {
+	for i := range x.stats {
+		if h.PUUID != "" && x.stats[i].Puuid == h.PUUID {
+			x.heroes[h.NetID] = &x.stats[i]
+			return
+		}
+	}
+	for i := range x.stats {
+		if int64(x.stats[i].ID) == int64(h.ParticipantID) {
+			x.heroes[h.NetID] = &x.stats[i]
+			return
+		}
+	}
+}
+
+func (x *Extractor) decodeChampionKill(p packets.Packet, r *payloadReader) Event {
+	e := ChampionKill{At: p.Time, Killer: x.Participant(r.u32()), Victim: x.Participant(p.NetID)}
+	n := int(r.u8())
+	for range n {
+		e.Assisters = append(e.Assisters, x.Participant(r.u32()))
+	}
+	return e
+}
+
+// Extract decodes every chunk of the replay in order and returns the events they contain.
+// Decoding stops at the first chunk or packet that cannot be read.
+func Extract(f *rofl.RoflFile, table Table) ([]Event, error) {
+	idx, err := f.SegmentIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	x := NewExtractor(f.Metadata, table)
+	pt := table.PacketTable()
+	var out []Event
+
+	for _, s := range idx.Chunks {
+		data, err := f.DecodeChunk(s.ID)
+		if err != nil {
+			return out, fmt.Errorf("chunk %d: %w", s.ID, err)
+		}
+
+		for p, err := range packets.NewChunk(data, pt).Packets() {
+			if err != nil {
+				return out, fmt.Errorf("chunk %d: %w", s.ID, err)
+			}
+			e, ok, err := x.Feed(p)
+			if err != nil {
+				return out, fmt.Errorf("chunk %d: %w", s.ID, err)
+			}
+			if ok {
+				out = append(out, e)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// payloadReader reads little endian values from a payload and remembers if it ran short,
+// so decoders can read every field and check once at the end.
+type payloadReader struct {
+	b     []byte
+	pos   int
+	short bool
+}
+
+func (r *payloadReader) bytes(n int) []byte {
+	if r.pos+n > len(r.b) {
+		r.short = true
+		r.pos = len(r.b)
+		return nil
+	}
+	v := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return v
+}
+
+func (r *payloadReader) u8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *payloadReader) u32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *payloadReader) err(p packets.Packet, kind Kind) error {
+	if !r.short {
+		return nil
+	}
+	return fmt.Errorf("%s packet %#x at %v: payload too short (%d bytes)", kind, p.ID, p.Time, len(p.Payload))
+}
@@ -0,0 +1,210 @@
+package events
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZiedYousfi/analolzer/mdr/internal/heroes"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl/packets"
+)
+
+const (
+	heroA  = 0x40000001
+	heroB  = 0x40000002
+	heroC  = 0x40000003
+	minion = 0x40000100
+)
+
+var testTable = Table{
+	0x01: KindHeroSpawn,
+	0x02: KindChampionKill,
+	0x03: KindBuildingKill,
+	0x04: KindEpicMonsterKill,
+	0x05: KindItemPurchased,
+	0x06: KindLevelUp,
+	0x07: KindSkillLevelUp,
+}
+
+func testMetadata() rofl.Metadata {
+	return rofl.Metadata{StatsJSON: []rofl.StatsJSON{
+		{ID: 1, Puuid: "puuid-a"},
+		{ID: 2, Puuid: "puuid-b"},
+		{ID: 3, Puuid: "puuid-c"},
+	}}
+}
+
+func spawn(netID, participantID uint32, puuid string) packets.Packet {
+	payload := binary.LittleEndian.AppendUint32(nil, participantID)
+	payload = append(payload, puuid...)
+	payload = append(payload, make([]byte, 78-len(puuid))...)
+	return packets.Packet{ID: 0x01, NetID: netID, Payload: payload}
+}
+
+// payload encodes values as the events store them: uint8 and uint32 little endian.
+func payload(values ...any) []byte {
+	var b []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case uint8:
+			b = append(b, v)
+		case uint32:
+			b = binary.LittleEndian.AppendUint32(b, v)
+		default:
+			panic("unsupported payload value")
+		}
+	}
+	return b
+}
+
+func TestExtractorFeed(t *testing.T) {
+	at := 90 * time.Second
+	packet := func(id uint16, netID uint32, values ...any) packets.Packet {
+		return packets.Packet{Time: at, ID: id, NetID: netID, Payload: payload(values...)}
+	}
+
+	tests := []struct {
+		name    string
+		packet  packets.Packet
+		want    func(m *rofl.Metadata) Event
+		wantErr bool
+	}{
+		{
+			name:   "champion kill",
+			packet: packet(0x02, heroB, uint32(heroA), uint8(2), uint32(heroC), uint32(minion)),
+			want: func(m *rofl.Metadata) Event {
+				return ChampionKill{
+					At:        at,
+					Killer:    Participant{NetID: heroA, Stats: &m.StatsJSON[0]},
+					Victim:    Participant{NetID: heroB, Stats: &m.StatsJSON[1]},
+					Assisters: []Participant{{NetID: heroC, Stats: &m.StatsJSON[2]}, {NetID: minion}},
+				}
+			},
+		},
+		{
+			name:   "champion kill without assist",
+			packet: packet(0x02, heroB, uint32(minion), uint8(0)),
+			want: func(m *rofl.Metadata) Event {
+				return ChampionKill{At: at, Killer: Participant{NetID: minion}, Victim: Participant{NetID: heroB, Stats: &m.StatsJSON[1]}}
+			},
+		},
+		{
+			name:   "building kill",
+			packet: packet(0x03, 0x50000001, uint32(heroA)),
+			want: func(m *rofl.Metadata) Event {
+				return BuildingKill{At: at, Killer: Participant{NetID: heroA, Stats: &m.StatsJSON[0]}, BuildingNetID: 0x50000001}
+			},
+		},
+		{
+			name:   "epic monster kill",
+			packet: packet(0x04, 0x60000001, uint32(heroC), uint8(MonsterBaron)),
+			want: func(m *rofl.Metadata) Event {
+				return EpicMonsterKill{At: at, Killer: Participant{NetID: heroC, Stats: &m.StatsJSON[2]}, Monster: MonsterBaron, MonsterNetID: 0x60000001}
+			},
+		},
+		{
+			name:   "item purchased",
+			packet: packet(0x05, heroA, uint32(3031), uint8(2)),
+			want: func(m *rofl.Metadata) Event {
+				return ItemPurchased{At: at, Participant: Participant{NetID: heroA, Stats: &m.StatsJSON[0]}, ItemID: 3031, Slot: 2}
+			},
+		},
+		{
+			name:   "level up",
+			packet: packet(0x06, heroB, uint8(6)),
+			want: func(m *rofl.Metadata) Event {
+				return LevelUp{At: at, Participant: Participant{NetID: heroB, Stats: &m.StatsJSON[1]}, Level: 6}
+			},
+		},
+		{
+			name:   "skill level up",
+			packet: packet(0x07, heroC, uint8(3), uint8(1)),
+			want: func(m *rofl.Metadata) Event {
+				return SkillLevelUp{At: at, Participant: Participant{NetID: heroC, Stats: &m.StatsJSON[2]}, Slot: 3, Level: 1}
+			},
+		},
+		{name: "hero spawn", packet: spawn(heroA, 1, "puuid-a")},
+		{name: "packet not in the table", packet: packet(0x08, heroA, uint8(1))},
+		{name: "short hero spawn", packet: packet(0x01, heroA, uint32(1)), wantErr: true},
+		{name: "short champion kill", packet: packet(0x02, heroB, uint32(heroA)), wantErr: true},
+		{name: "short assister list", packet: packet(0x02, heroB, uint32(heroA), uint8(2), uint32(heroC)), wantErr: true},
+		{name: "short building kill", packet: packet(0x03, 0x50000001, uint8(1)), wantErr: true},
+		{name: "short epic monster kill", packet: packet(0x04, 0x60000001, uint32(heroC)), wantErr: true},
+		{name: "short item purchased", packet: packet(0x05, heroA, uint32(3031)), wantErr: true},
+		{name: "short level up", packet: packet(0x06, heroB), wantErr: true},
+		{name: "short skill level up", packet: packet(0x07, heroC, uint8(3)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testMetadata()
+			x := NewExtractor(m, testTable)
+			for _, p := range []packets.Packet{spawn(heroA, 1, "puuid-a"), spawn(heroB, 2, "puuid-b"), spawn(heroC, 3, "puuid-c")} {
+				if _, _, err := x.Feed(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			e, ok, err := x.Feed(tt.packet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Feed() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				if ok || e != nil {
+					t.Errorf("Feed() = %+v, %v, want no event", e, ok)
+				}
+				return
+			}
+			if want := tt.want(&m); !ok || !reflect.DeepEqual(e, want) {
+				t.Errorf("Feed() = %+v, %v, want %+v, true", e, ok, want)
+			}
+		})
+	}
+}
+
+func TestBindHero(t *testing.T) {
+	tests := []struct {
+		name  string
+		spawn heroes.Spawn
+		// want indexes StatsJSON, -1 when the hero stays unknown
+		want int
+	}{
+		{name: "PUUID", spawn: heroes.Spawn{NetID: heroA, ParticipantID: 1, PUUID: "puuid-b"}, want: 1},
+		{name: "PUUID wins over the participant ID", spawn: heroes.Spawn{NetID: heroA, ParticipantID: 1, PUUID: "puuid-c"}, want: 2},
+		{name: "participant ID without PUUID", spawn: heroes.Spawn{NetID: heroA, ParticipantID: 2}, want: 1},
+		{name: "participant ID for an unknown PUUID", spawn: heroes.Spawn{NetID: heroA, ParticipantID: 3, PUUID: "puuid-x"}, want: 2},
+		{name: "neither known", spawn: heroes.Spawn{NetID: heroA, ParticipantID: 9, PUUID: "puuid-x"}, want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testMetadata()
+			x := NewExtractor(m, testTable)
+			x.bindHero(tt.spawn)
+
+			got := x.Participant(tt.spawn.NetID)
+			if tt.want < 0 {
+				if got.Known() {
+					t.Errorf("Participant() = %+v, want an unknown participant", got)
+				}
+				return
+			}
+			if got.Stats != &m.StatsJSON[tt.want] {
+				t.Errorf("Participant() is %q, want %q", got.PUUID(), m.StatsJSON[tt.want].Puuid)
+			}
+		})
+	}
+}
+
+func TestTableFrom(t *testing.T) {
+	pt := packets.Table{0x01: heroes.PacketSpawn, 0x02: "ChampionKill", 0x03: heroes.PacketMovement, 0x04: "Unknown"}
+	want := Table{0x01: KindHeroSpawn, 0x02: KindChampionKill}
+
+	got := TableFrom(pt)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TableFrom() = %v, want %v", got, want)
+	}
+	if back := got.PacketTable(); !reflect.DeepEqual(back, packets.Table{0x01: "HeroSpawn", 0x02: "ChampionKill"}) {
+		t.Errorf("PacketTable() = %v", back)
+	}
+}
@@ -0,0 +1,47 @@
+// Package heroes decodes the packets binding the heroes of a replay to its participants.
+//
+// The layouts below are inferred and have not been checked against a real replay,
+// nor has any packet table naming these packets. The package stays internal until
+// a table verified for a patch ships with them.
+package heroes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl/packets"
+)
+
+// Names of the packets decoded by more than one package. Tables use them as values.
+const (
+	PacketSpawn    = "HeroSpawn"
+	PacketMovement = "Movement"
+)
+
+const puuidLength = 78
+
+// Spawn binds the net ID of a hero to a participant of the game.
+// The packet net ID is the hero, the payload is:
+//
+//	offset  size  field
+//	0x00    4     participant ID
+//	0x04    78    PUUID (NUL padded)
+type Spawn struct {
+	NetID         uint32
+	ParticipantID uint32
+	PUUID         string
+}
+
+// DecodeSpawn decodes a packet named PacketSpawn.
+func DecodeSpawn(p packets.Packet) (Spawn, error) {
+	if len(p.Payload) < 4+puuidLength {
+		return Spawn{}, fmt.Errorf("%s packet at %v: payload too short (%d bytes)", PacketSpawn, p.Time, len(p.Payload))
+	}
+
+	return Spawn{
+		NetID:         p.NetID,
+		ParticipantID: binary.LittleEndian.Uint32(p.Payload),
+		PUUID:         string(bytes.TrimRight(p.Payload[4:4+puuidLength], "\x00")),
+	}, nil
+}
@@ -0,0 +1,240 @@
+// Package parquet writes flat Apache Parquet files: a list of optional columns holding
+// 64-bit integers, doubles or UTF-8 strings, in the order they are given. That is what
+// the exports need. The files themselves are written by github.com/parquet-go/parquet-go,
+// this package only maps the columns and rows of the exports onto it.
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+)
+
+// createdBy is recorded in every file, readers use it to work around writer bugs.
+const createdBy = "github.com/ZiedYousfi/analolzer/mdr"
+
+// Kind is the type of the values of a column.
+type Kind uint8
+
+const (
+	KindInt64 Kind = iota + 1
+	KindDouble
+	KindString
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindInt64:
+		return "int64"
+	case KindDouble:
+		return "double"
+	case KindString:
+		return "string"
+	default:
+		return fmt.Sprintf("Kind(%d)", uint8(k))
+	}
+}
+
+// node returns the optional Parquet node of a column of the kind.
+func (k Kind) node() pq.Node {
+	switch k {
+	case KindInt64:
+		return pq.Optional(pq.Leaf(pq.Int64Type))
+	case KindDouble:
+		return pq.Optional(pq.Leaf(pq.DoubleType))
+	default:
+		return pq.Optional(pq.String())
+	}
+}
+
+// Column describes a column. Every column is optional, a row may leave it null.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Codec is the compression of the pages.
+type Codec uint8
+
+const (
+	CodecNone Codec = iota
+	CodecSnappy
+	CodecZstd
+)
+
+func (c Codec) codec() compress.Codec {
+	switch c {
+	case CodecSnappy:
+		return &pq.Snappy
+	case CodecZstd:
+		return &pq.Zstd
+	default:
+		return &pq.Uncompressed
+	}
+}
+
+// Option configures a Writer.
+type Option func(*options)
+
+type options struct {
+	codec        Codec
+	rowGroupSize int
+}
+
+// WithCodec sets the compression of the pages, Snappy by default.
+func WithCodec(c Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithRowGroupSize sets the number of rows buffered in memory before they are written
+// as a row group, 16384 by default.
+func WithRowGroupSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.rowGroupSize = n
+		}
+	}
+}
+
+// group is the root of the schema. parquet.Group sorts its fields by name, group keeps
+// them in the order of the columns.
+type group struct {
+	pq.Group
+	fields []pq.Field
+}
+
+func newGroup(columns []Column) group {
+	g := group{Group: make(pq.Group, len(columns)), fields: make([]pq.Field, len(columns))}
+	for i, c := range columns {
+		g.Group[c.Name] = c.Kind.node()
+		g.fields[i] = pq.Group{c.Name: g.Group[c.Name]}.Fields()[0]
+	}
+	return g
+}
+
+func (g group) Fields() []pq.Field {
+	return g.fields
+}
+
+// Writer writes a Parquet file. Rows are buffered and written a row group at a time;
+// Close must be called to write the footer.
+type Writer struct {
+	w       *pq.Writer
+	columns []Column
+	rows    int64
+	row     pq.Row
+	err     error
+}
+
+// NewWriter returns a Writer writing a file with the given columns to w.
+func NewWriter(w io.Writer, columns []Column, opts ...Option) (*Writer, error) {
+	o := options{codec: CodecSnappy, rowGroupSize: 16384}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	seen := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		if c.Name == "" || seen[c.Name] {
+			return nil, fmt.Errorf("parquet: invalid or duplicate column name %q", c.Name)
+		}
+		if c.Kind < KindInt64 || c.Kind > KindString {
+			return nil, fmt.Errorf("parquet: column %s: invalid kind %v", c.Name, c.Kind)
+		}
+		seen[c.Name] = true
+	}
+
+	config, err := pq.NewWriterConfig(
+		pq.NewSchema("schema", newGroup(columns)),
+		pq.Compression(o.codec.codec()),
+		pq.MaxRowsPerRowGroup(int64(o.rowGroupSize)),
+		pq.CreatedBy(createdBy, "", ""),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("parquet: %w", err)
+	}
+
+	return &Writer{
+		w:       pq.NewWriter(w, config),
+		columns: append([]Column(nil), columns...),
+		row:     make(pq.Row, len(columns)),
+	}, nil
+}
+
+// Columns returns the columns of the file.
+func (w *Writer) Columns() []Column {
+	return append([]Column(nil), w.columns...)
+}
+
+// Rows returns the number of rows written so far, buffered ones included.
+func (w *Writer) Rows() int64 {
+	return w.rows
+}
+
+// WriteRow adds a row, holding one value per column in the order of the columns:
+// nil for a null, or an int64, float64 or string matching the kind of the column.
+func (w *Writer) WriteRow(row []any) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: row has %d values, want %d", len(row), len(w.columns))
+	}
+
+	for i, v := range row {
+		var value pq.Value
+		switch v := v.(type) {
+		case nil:
+			w.row[i] = pq.NullValue().Level(0, 0, i)
+			continue
+		case int64:
+			value = pq.Int64Value(v)
+		case float64:
+			value = pq.DoubleValue(v)
+		case string:
+			value = pq.ByteArrayValue([]byte(v))
+		}
+		if !accepts(w.columns[i].Kind, v) {
+			return fmt.Errorf("parquet: column %s: %T value in a %v column", w.columns[i].Name, v, w.columns[i].Kind)
+		}
+		w.row[i] = value.Level(0, 1, i)
+	}
+
+	if _, err := w.w.WriteRows([]pq.Row{w.row}); err != nil {
+		w.err = fmt.Errorf("parquet: %w", err)
+		return w.err
+	}
+	w.rows++
+	return nil
+}
+
+func accepts(k Kind, v any) bool {
+	switch v.(type) {
+	case int64:
+		return k == KindInt64
+	case float64:
+		return k == KindDouble
+	case string:
+		return k == KindString
+	default:
+		return false
+	}
+}
+
+// Close writes the buffered rows and the footer. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.w.Close(); err != nil {
+		w.err = fmt.Errorf("parquet: %w", err)
+		return w.err
+	}
+	w.err = errors.New("parquet: writer is closed")
+	return nil
+}
@@ -0,0 +1,305 @@
+package parquet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// parquetFile is what readParquet decodes of a file.
+type parquetFile struct {
+	columns   []Column
+	rows      [][]any
+	rowGroups int
+	codecs    []string
+}
+
+// readParquet reads a file back with parquet-go, checking that its schema is the flat
+// list of optional columns Writer describes.
+func readParquet(data []byte) (parquetFile, error) {
+	pf, err := pq.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return parquetFile{}, err
+	}
+	if got := pf.Metadata().CreatedBy; !strings.HasPrefix(got, createdBy) {
+		return parquetFile{}, fmt.Errorf("created_by = %q", got)
+	}
+
+	var f parquetFile
+	for _, field := range pf.Schema().Fields() {
+		if !field.Leaf() || !field.Optional() {
+			return parquetFile{}, fmt.Errorf("column %s is not an optional leaf", field.Name())
+		}
+		var kind Kind
+		switch field.Type().Kind() {
+		case pq.Int64:
+			kind = KindInt64
+		case pq.Double:
+			kind = KindDouble
+		case pq.ByteArray:
+			if lt := field.Type().LogicalType(); lt == nil || !isString(lt.Value) {
+				return parquetFile{}, fmt.Errorf("string column %s is not UTF8", field.Name())
+			}
+			kind = KindString
+		}
+		f.columns = append(f.columns, Column{Name: field.Name(), Kind: kind})
+	}
+
+	for _, rg := range pf.Metadata().RowGroups {
+		for _, chunk := range rg.Columns {
+			f.codecs = append(f.codecs, chunk.MetaData.Codec.String())
+		}
+	}
+	for _, rg := range pf.RowGroups() {
+		rows := make([]pq.Row, rg.NumRows())
+		r := rg.Rows()
+		n, err := r.ReadRows(rows)
+		r.Close()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return parquetFile{}, err
+		}
+		if n != len(rows) {
+			return parquetFile{}, fmt.Errorf("read %d rows of a row group of %d", n, len(rows))
+		}
+		for _, row := range rows {
+			values := make([]any, len(f.columns))
+			for _, v := range row {
+				if v.IsNull() {
+					continue
+				}
+				switch f.columns[v.Column()].Kind {
+				case KindInt64:
+					values[v.Column()] = v.Int64()
+				case KindDouble:
+					values[v.Column()] = v.Double()
+				case KindString:
+					values[v.Column()] = string(v.ByteArray())
+				}
+			}
+			f.rows = append(f.rows, values)
+		}
+		f.rowGroups++
+	}
+
+	if pf.NumRows() != int64(len(f.rows)) {
+		return parquetFile{}, fmt.Errorf("footer announces %d rows, row groups hold %d", pf.NumRows(), len(f.rows))
+	}
+	return f, nil
+}
+
+func isString(v format.LogicalTypeValue) bool {
+	_, ok := v.(*format.StringType)
+	return ok
+}
+
+func TestRoundTrip(t *testing.T) {
+	mixed := []Column{{"id", KindInt64}, {"ratio", KindDouble}, {"name", KindString}}
+
+	// More than 15 columns, the schema and column chunk lists get a long header
+	var wide []Column
+	wideRow := []any{}
+	for i := range 20 {
+		kind := []Kind{KindInt64, KindDouble, KindString}[i%3]
+		wide = append(wide, Column{Name: fmt.Sprintf("c%02d", i), Kind: kind})
+		wideRow = append(wideRow, map[Kind]any{KindInt64: int64(i), KindDouble: float64(i) / 4, KindString: strings.Repeat("x", i)}[kind])
+	}
+
+	many := func(n int, row func(i int) []any) [][]any {
+		rows := make([][]any, n)
+		for i := range rows {
+			rows[i] = row(i)
+		}
+		return rows
+	}
+
+	tests := []struct {
+		name          string
+		columns       []Column
+		rows          [][]any
+		rowGroupSize  int
+		wantRowGroups int
+	}{
+		{name: "no rows", columns: mixed, wantRowGroups: 0},
+		{
+			name:    "one row",
+			columns: mixed,
+			rows:    [][]any{{int64(math.MinInt64), math.Inf(-1), "héllo"}},
+			// One row group for the buffered rows, written on Close
+			wantRowGroups: 1,
+		},
+		{
+			name:    "nulls",
+			columns: mixed,
+			rows: [][]any{
+				{nil, nil, nil},
+				{int64(1), nil, ""},
+				{nil, 0.5, nil},
+				{int64(math.MaxInt64), -0.25, "last"},
+			},
+			wantRowGroups: 1,
+		},
+		{
+			name:    "long null runs",
+			columns: mixed,
+			rows: many(1000, func(i int) []any {
+				if i%300 == 299 {
+					return []any{int64(i), float64(i), fmt.Sprint(i)}
+				}
+				return []any{nil, nil, nil}
+			}),
+			wantRowGroups: 1,
+		},
+		{
+			name:    "several row groups",
+			columns: mixed,
+			rows: many(10, func(i int) []any {
+				if i%4 == 0 {
+					return []any{nil, float64(i), nil}
+				}
+				return []any{int64(i), nil, fmt.Sprint("row", i)}
+			}),
+			rowGroupSize:  3,
+			wantRowGroups: 4,
+		},
+		{
+			name:          "full row groups only",
+			columns:       mixed,
+			rows:          many(6, func(i int) []any { return []any{int64(i), float64(i), fmt.Sprint(i)} }),
+			rowGroupSize:  3,
+			wantRowGroups: 2,
+		},
+		{
+			name:          "more than 15 columns",
+			columns:       wide,
+			rows:          [][]any{wideRow, make([]any, len(wide)), wideRow},
+			rowGroupSize:  2,
+			wantRowGroups: 2,
+		},
+	}
+	for _, codec := range []Codec{CodecNone, CodecSnappy, CodecZstd} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/codec %d", tt.name, codec), func(t *testing.T) {
+				var buf bytes.Buffer
+				w, err := NewWriter(&buf, tt.columns, WithCodec(codec), WithRowGroupSize(tt.rowGroupSize))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, row := range tt.rows {
+					if err := w.WriteRow(row); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if got := w.Rows(); got != int64(len(tt.rows)) {
+					t.Errorf("Rows() = %d, want %d", got, len(tt.rows))
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				f, err := readParquet(buf.Bytes())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(f.columns, tt.columns) {
+					t.Errorf("columns = %v, want %v", f.columns, tt.columns)
+				}
+				if f.rowGroups != tt.wantRowGroups {
+					t.Errorf("%d row groups, want %d", f.rowGroups, tt.wantRowGroups)
+				}
+				for _, got := range f.codecs {
+					if want := codec.codec().CompressionCodec().String(); got != want {
+						t.Errorf("column chunk compressed with %s, want %s", got, want)
+						break
+					}
+				}
+				if len(f.rows) != len(tt.rows) || (len(f.rows) > 0 && !reflect.DeepEqual(f.rows, tt.rows)) {
+					t.Errorf("rows = %v, want %v", f.rows, tt.rows)
+				}
+			})
+		}
+	}
+}
+
+func TestNewWriterErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []Column
+	}{
+		{name: "empty name", columns: []Column{{"", KindInt64}}},
+		{name: "duplicate name", columns: []Column{{"a", KindInt64}, {"a", KindString}}},
+		{name: "invalid kind", columns: []Column{{"a", 0}}},
+		{name: "unknown kind", columns: []Column{{"a", KindString + 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWriter(&bytes.Buffer{}, tt.columns); err == nil {
+				t.Error("NewWriter() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestWriteRowErrors(t *testing.T) {
+	columns := []Column{{"id", KindInt64}, {"name", KindString}}
+	tests := []struct {
+		name string
+		row  []any
+	}{
+		{name: "too few values", row: []any{int64(1)}},
+		{name: "too many values", row: []any{int64(1), "a", "b"}},
+		{name: "wrong type", row: []any{"1", "a"}},
+		{name: "int instead of int64", row: []any{1, "a"}},
+		{name: "unsupported type", row: []any{int64(1), []byte("a")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, columns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteRow(tt.row); err == nil {
+				t.Fatal("WriteRow() succeeded, want an error")
+			}
+
+			// A rejected row leaves the file as it was
+			if err := w.WriteRow([]any{int64(1), "a"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			f, err := readParquet(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := [][]any{{int64(1), "a"}}; !reflect.DeepEqual(f.rows, want) {
+				t.Errorf("rows = %v, want %v", f.rows, want)
+			}
+		})
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	w, err := NewWriter(&bytes.Buffer{}, []Column{{"id", KindInt64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]any{int64(1)}); err == nil {
+		t.Error("WriteRow() after Close succeeded, want an error")
+	}
+	if err := w.Close(); err == nil {
+		t.Error("second Close succeeded, want an error")
+	}
+}
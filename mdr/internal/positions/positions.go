@@ -0,0 +1,165 @@
+// Package positions tracks where each hero was on the map over the course of a replay.
+//
+// Hero positions come from the movement packets of the replay chunks. The packet net ID
+// is the hero and the payload holds its map coordinates as two little endian float32, X then Z.
+// Heroes are bound to participants through hero spawn packets, see heroes.Spawn.
+//
+// Like the hero spawn, the movement layout is inferred and has not been checked against
+// a real replay, and no packet table names these packets for any patch. The package is
+// internal until one is verified, RoflFile has no position timeline before then.
+package positions
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/ZiedYousfi/analolzer/mdr/internal/heroes"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl/packets"
+)
+
+// Sample is the position of a hero at a given game time.
+type Sample struct {
+	Time time.Duration
+	X    float32
+	Z    float32
+}
+
+// Track is every known position of one participant, in game order.
+type Track struct {
+	NetID         uint32
+	ParticipantID uint32
+	PUUID         string
+	Samples       []Sample
+}
+
+// At returns the position of the participant at t, linearly interpolated between the two
+// surrounding samples. ok is false before the first sample. After the last sample
+// the participant is assumed not to have moved.
+func (tr *Track) At(t time.Duration) (s Sample, ok bool) {
+	i, found := slices.BinarySearchFunc(tr.Samples, t, func(s Sample, t time.Duration) int {
+		return cmp.Compare(s.Time, t)
+	})
+	switch {
+	case found:
+		return tr.Samples[i], true
+	case i == 0:
+		return Sample{}, false
+	case i == len(tr.Samples):
+		s = tr.Samples[i-1]
+		s.Time = t
+		return s, true
+	}
+
+	a, b := tr.Samples[i-1], tr.Samples[i]
+	f := float32(t-a.Time) / float32(b.Time-a.Time)
+	return Sample{Time: t, X: a.X + (b.X-a.X)*f, Z: a.Z + (b.Z-a.Z)*f}, true
+}
+
+// Timeline is every participant's position sampled at a fixed interval.
+type Timeline struct {
+	Interval time.Duration
+	Tracks   []Track
+}
+
+// ErrMissingPacket is returned when the packet table does not name the packets the tracker needs.
+var ErrMissingPacket = errors.New("packet table has no hero spawn or movement packet")
+
+// Tracker collects hero positions from packets fed in game order.
+type Tracker struct {
+	heroSpawnID uint16
+	movementID  uint16
+	tracks      map[uint32]*Track
+}
+
+// NewTracker returns a tracker for a replay whose packets are named by table.
+func NewTracker(table packets.Table) (*Tracker, error) {
+	heroSpawnID, ok := table.ID(heroes.PacketSpawn)
+	if !ok {
+		return nil, ErrMissingPacket
+	}
+	movementID, ok := table.ID(heroes.PacketMovement)
+	if !ok {
+		return nil, ErrMissingPacket
+	}
+
+	return &Tracker{
+		heroSpawnID: heroSpawnID,
+		movementID:  movementID,
+		tracks:      make(map[uint32]*Track),
+	}, nil
+}
+
+// Feed records the packet if it is a hero spawn or the movement of a known hero.
+func (t *Tracker) Feed(p packets.Packet) error {
+	switch p.ID {
+	case t.heroSpawnID:
+		h, err := heroes.DecodeSpawn(p)
+		if err != nil {
+			return err
+		}
+		if _, ok := t.tracks[h.NetID]; !ok {
+			t.tracks[h.NetID] = &Track{NetID: h.NetID, ParticipantID: h.ParticipantID, PUUID: h.PUUID}
+		}
+	case t.movementID:
+		tr, ok := t.tracks[p.NetID]
+		if !ok {
+			// Minions, monsters and other units move too, only heroes are tracked
+			return nil
+		}
+		if len(p.Payload) < 8 {
+			return fmt.Errorf("%s packet at %v: payload too short (%d bytes)", heroes.PacketMovement, p.Time, len(p.Payload))
+		}
+		s := Sample{
+			Time: p.Time,
+			X:    math.Float32frombits(binary.LittleEndian.Uint32(p.Payload[0:])),
+			Z:    math.Float32frombits(binary.LittleEndian.Uint32(p.Payload[4:])),
+		}
+		if n := len(tr.Samples); n > 0 && tr.Samples[n-1].Time == s.Time {
+			tr.Samples[n-1] = s
+		} else {
+			tr.Samples = append(tr.Samples, s)
+		}
+	}
+	return nil
+}
+
+// Tracks returns every raw position update per participant, sorted by participant ID.
+func (t *Tracker) Tracks() []Track {
+	out := make([]Track, 0, len(t.tracks))
+	for _, tr := range t.tracks {
+		out = append(out, *tr)
+	}
+	slices.SortFunc(out, func(a, b Track) int {
+		if c := cmp.Compare(a.ParticipantID, b.ParticipantID); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.NetID, b.NetID)
+	})
+	return out
+}
+
+// Timeline resamples every track at the given interval, from the start of the game to end.
+// Times before the first known position of a participant are skipped.
+func (t *Tracker) Timeline(interval, end time.Duration) (Timeline, error) {
+	if interval <= 0 {
+		return Timeline{}, fmt.Errorf("invalid sampling interval %v", interval)
+	}
+
+	tl := Timeline{Interval: interval}
+	for _, tr := range t.Tracks() {
+		sampled := Track{NetID: tr.NetID, ParticipantID: tr.ParticipantID, PUUID: tr.PUUID}
+		for at := time.Duration(0); at <= end; at += interval {
+			if s, ok := tr.At(at); ok {
+				sampled.Samples = append(sampled.Samples, s)
+			}
+		}
+		tl.Tracks = append(tl.Tracks, sampled)
+	}
+
+	return tl, nil
+}
@@ -0,0 +1,251 @@
+package positions
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZiedYousfi/analolzer/mdr/internal/heroes"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl/packets"
+)
+
+const (
+	spawnID    = 0x0101
+	movementID = 0x0202
+)
+
+var testTable = packets.Table{spawnID: heroes.PacketSpawn, movementID: heroes.PacketMovement}
+
+func spawn(at time.Duration, netID, participantID uint32, puuid string) packets.Packet {
+	payload := binary.LittleEndian.AppendUint32(nil, participantID)
+	payload = append(payload, puuid...)
+	payload = append(payload, make([]byte, 78-len(puuid))...)
+	return packets.Packet{Time: at, ID: spawnID, NetID: netID, Payload: payload}
+}
+
+func move(at time.Duration, netID uint32, x, z float32) packets.Packet {
+	payload := binary.LittleEndian.AppendUint32(nil, math.Float32bits(x))
+	payload = binary.LittleEndian.AppendUint32(payload, math.Float32bits(z))
+	return packets.Packet{Time: at, ID: movementID, NetID: netID, Payload: payload}
+}
+
+func TestNewTracker(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   packets.Table
+		wantErr error
+	}{
+		{name: "both packets", table: testTable},
+		{name: "no movement", table: packets.Table{spawnID: heroes.PacketSpawn}, wantErr: ErrMissingPacket},
+		{name: "no hero spawn", table: packets.Table{movementID: heroes.PacketMovement}, wantErr: ErrMissingPacket},
+		{name: "no table", wantErr: ErrMissingPacket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTracker(tt.table); !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewTracker() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrackerFeed(t *testing.T) {
+	const heroA, heroB, minion = 0x40000001, 0x40000002, 0x40000100
+	s := time.Second
+
+	tests := []struct {
+		name    string
+		packets []packets.Packet
+		want    []Track
+		wantErr bool
+	}{
+		{
+			name: "spawn then moves",
+			packets: []packets.Packet{
+				spawn(0, heroA, 1, "puuid-a"),
+				move(1*s, heroA, 100, 200),
+				move(2*s, heroA, 150, 250),
+			},
+			want: []Track{{NetID: heroA, ParticipantID: 1, PUUID: "puuid-a", Samples: []Sample{
+				{Time: 1 * s, X: 100, Z: 200},
+				{Time: 2 * s, X: 150, Z: 250},
+			}}},
+		},
+		{
+			name: "moves of other units and before the spawn are ignored",
+			packets: []packets.Packet{
+				move(1*s, heroA, 1, 1),
+				move(1*s, minion, 2, 2),
+				spawn(2*s, heroA, 1, "puuid-a"),
+				move(3*s, heroA, 3, 3),
+			},
+			want: []Track{{NetID: heroA, ParticipantID: 1, PUUID: "puuid-a", Samples: []Sample{{Time: 3 * s, X: 3, Z: 3}}}},
+		},
+		{
+			name: "the last move of a tick wins",
+			packets: []packets.Packet{
+				spawn(0, heroA, 1, ""),
+				move(1*s, heroA, 1, 1),
+				move(1*s, heroA, 2, 2),
+			},
+			want: []Track{{NetID: heroA, ParticipantID: 1, Samples: []Sample{{Time: 1 * s, X: 2, Z: 2}}}},
+		},
+		{
+			name: "a second spawn keeps the first binding and the samples",
+			packets: []packets.Packet{
+				spawn(0, heroA, 1, "puuid-a"),
+				move(1*s, heroA, 1, 1),
+				spawn(2*s, heroA, 7, "puuid-x"),
+			},
+			want: []Track{{NetID: heroA, ParticipantID: 1, PUUID: "puuid-a", Samples: []Sample{{Time: 1 * s, X: 1, Z: 1}}}},
+		},
+		{
+			name: "tracks sorted by participant ID",
+			packets: []packets.Packet{
+				spawn(0, heroA, 2, "puuid-a"),
+				spawn(0, heroB, 1, "puuid-b"),
+			},
+			want: []Track{
+				{NetID: heroB, ParticipantID: 1, PUUID: "puuid-b"},
+				{NetID: heroA, ParticipantID: 2, PUUID: "puuid-a"},
+			},
+		},
+		{
+			name:    "other packets are ignored",
+			packets: []packets.Packet{{Time: s, ID: 0x0303, NetID: heroA, Payload: []byte{1}}},
+		},
+		{
+			name:    "short hero spawn",
+			packets: []packets.Packet{{ID: spawnID, NetID: heroA, Payload: make([]byte, 81)}},
+			wantErr: true,
+		},
+		{
+			name: "short movement",
+			packets: []packets.Packet{
+				spawn(0, heroA, 1, ""),
+				{Time: s, ID: movementID, NetID: heroA, Payload: make([]byte, 7)},
+			},
+			want:    []Track{{NetID: heroA, ParticipantID: 1}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, err := NewTracker(testTable)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range tt.packets {
+				if err = tracker.Feed(p); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Feed() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := tracker.Tracks(); len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("Tracks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackAt(t *testing.T) {
+	s := time.Second
+	track := Track{Samples: []Sample{{Time: 1 * s, X: 0, Z: 0}, {Time: 3 * s, X: 100, Z: -50}}}
+
+	tests := []struct {
+		name   string
+		track  Track
+		at     time.Duration
+		want   Sample
+		wantOK bool
+	}{
+		{name: "before the first sample", track: track, at: 0},
+		{name: "on the first sample", track: track, at: 1 * s, want: Sample{Time: 1 * s}, wantOK: true},
+		{name: "halfway", track: track, at: 2 * s, want: Sample{Time: 2 * s, X: 50, Z: -25}, wantOK: true},
+		{name: "a quarter of the way", track: track, at: 1500 * time.Millisecond, want: Sample{Time: 1500 * time.Millisecond, X: 25, Z: -12.5}, wantOK: true},
+		{name: "on the last sample", track: track, at: 3 * s, want: Sample{Time: 3 * s, X: 100, Z: -50}, wantOK: true},
+		{name: "after the last sample", track: track, at: 10 * s, want: Sample{Time: 10 * s, X: 100, Z: -50}, wantOK: true},
+		{name: "no sample", at: 1 * s},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.track.At(tt.at)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("At(%v) = %+v, %v, want %+v, %v", tt.at, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	const heroA, heroB = 0x40000001, 0x40000002
+	s := time.Second
+
+	tracker, err := NewTracker(testTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []packets.Packet{
+		spawn(0, heroA, 1, "puuid-a"),
+		spawn(0, heroB, 2, "puuid-b"),
+		move(1500*time.Millisecond, heroA, 0, 0),
+		move(3500*time.Millisecond, heroA, 100, 200),
+	} {
+		if err := tracker.Feed(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		end      time.Duration
+		want     Timeline
+		wantErr  bool
+	}{
+		{
+			name:     "every second",
+			interval: s,
+			end:      4 * s,
+			// Nothing is known of hero A before 1.5s, hero B never moved
+			want: Timeline{Interval: s, Tracks: []Track{
+				{NetID: heroA, ParticipantID: 1, PUUID: "puuid-a", Samples: []Sample{
+					{Time: 2 * s, X: 25, Z: 50},
+					{Time: 3 * s, X: 75, Z: 150},
+					{Time: 4 * s, X: 100, Z: 200},
+				}},
+				{NetID: heroB, ParticipantID: 2, PUUID: "puuid-b"},
+			}},
+		},
+		{
+			name:     "end between two ticks",
+			interval: 2 * s,
+			end:      5 * s,
+			want: Timeline{Interval: 2 * s, Tracks: []Track{
+				{NetID: heroA, ParticipantID: 1, PUUID: "puuid-a", Samples: []Sample{
+					{Time: 2 * s, X: 25, Z: 50},
+					{Time: 4 * s, X: 100, Z: 200},
+				}},
+				{NetID: heroB, ParticipantID: 2, PUUID: "puuid-b"},
+			}},
+		},
+		{name: "zero interval", interval: 0, end: 4 * s, wantErr: true},
+		{name: "negative interval", interval: -s, end: 4 * s, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tracker.Timeline(tt.interval, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Timeline() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Timeline() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package schema
+
+// Change is a stat whose type differs between two schemas.
+type Change struct {
+	Key string
+	Old Type
+	New Type
+}
+
+// Drift lists what changed between two schemas, every list sorted by key.
+type Drift struct {
+	Added   []string
+	Removed []string
+	Changed []Change
+}
+
+// Empty reports whether the schemas are the same.
+func (d Drift) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares the schema of an older corpus of replays with a newer one.
+// Nulls do not count as a type change: a stat that is sometimes null still decodes the same.
+func Diff(older, newer Schema) Drift {
+	var d Drift
+	for _, key := range newer.Keys() {
+		o, ok := older[key]
+		if !ok {
+			d.Added = append(d.Added, key)
+			continue
+		}
+		if o, n := o&^TypeNull, newer[key]&^TypeNull; o != 0 && n != 0 && o != n {
+			d.Changed = append(d.Changed, Change{Key: key, Old: o, New: n})
+		}
+	}
+	for _, key := range older.Keys() {
+		if _, ok := newer[key]; !ok {
+			d.Removed = append(d.Removed, key)
+		}
+	}
+	return d
+}
+
+// Accepts reports whether a field of the given Go type can decode values of type observed.
+// Nulls decode to the zero value of any field, and json.RawMessage fields take anything.
+func Accepts(goType string, observed Type) bool {
+	field := GoTypeSchema(goType)
+	return field == 0 || (observed&^TypeNull)&^field == 0
+}
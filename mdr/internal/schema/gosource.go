@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Field is a field of a Go struct decoding stats.
+type Field struct {
+	Name   string
+	Key    string
+	GoType string
+}
+
+// ReadStruct returns the exported fields of the struct called typeName in the Go file at path,
+// keyed by JSON key. It reads the source rather than the compiled type so that a generator can
+// keep the field names of the struct it replaces.
+func ReadStruct(path, typeName string) (map[string]Field, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var st *ast.StructType
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok && ts.Name.Name == typeName {
+			st, _ = ts.Type.(*ast.StructType)
+			return false
+		}
+		return st == nil
+	})
+	if st == nil {
+		return nil, fmt.Errorf("%s: struct %s not found", path, typeName)
+	}
+
+	fields := make(map[string]Field)
+	for _, f := range st.Fields.List {
+		if f.Tag == nil || len(f.Names) != 1 || !f.Names[0].IsExported() {
+			continue
+		}
+		tag, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: field %s: %w", path, f.Names[0].Name, err)
+		}
+		key, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		fields[key] = Field{Name: f.Names[0].Name, Key: key, GoType: types.ExprString(f.Type)}
+	}
+	return fields, nil
+}
+
+// StructFields is ReadStruct for a compiled struct type, e.g. reflect.TypeFor[rofl.StatsJSON]().
+// Types of the struct's own package are written without qualifier, as in its source.
+func StructFields(t reflect.Type) map[string]Field {
+	qualifier := path.Base(t.PkgPath()) + "."
+
+	fields := make(map[string]Field, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		fields[key] = Field{Name: f.Name, Key: key, GoType: strings.ReplaceAll(f.Type.String(), qualifier, "")}
+	}
+	return fields
+}
+
+// GoTypeSchema returns the types a field of the given Go type accepts, the reverse of the
+// mapping done by the generator.
+func GoTypeSchema(goType string) Type {
+	switch goType {
+	case "FlexInt64":
+		return TypeInteger
+	case "FlexFloat64":
+		return TypeInteger | TypeNumber
+	case "string":
+		return TypeString
+	case "bool":
+		return TypeBool
+	case "json.RawMessage":
+		return 0
+	default:
+		// Generated union of a number and a string, e.g. *RiotIDTagLine
+		return TypeInteger | TypeString
+	}
+}
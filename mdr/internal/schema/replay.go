@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+// ReadReplay returns the schema of the participant stats of the replay at path.
+// The metadata is located by the rofl header parsing but left undecoded, so that
+// every key is seen, including the ones StatsJSON has no field for.
+func ReadReplay(path string) (Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	data, err := rofl.ReadMetadataJSON(f, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var metadata struct {
+		StatsJSON string `json:"statsJson"`
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("%s: error decoding metadata: %w", path, err)
+	}
+
+	var participants []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(metadata.StatsJSON), &participants); err != nil {
+		return nil, fmt.Errorf("%s: error decoding statsJson: %w", path, err)
+	}
+
+	s := make(Schema)
+	for _, p := range participants {
+		s.Add(p)
+	}
+	return s, nil
+}
+
+// Load merges the schemas of every replay in paths. Directories are walked
+// for .rofl files. It returns the number of replays read.
+func Load(paths []string) (Schema, int, error) {
+	s := make(Schema)
+	n := 0
+
+	add := func(path string) error {
+		rs, err := ReadReplay(path)
+		if err != nil {
+			return err
+		}
+		s.Merge(rs)
+		n++
+		return nil
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !info.IsDir() {
+			if err := add(path); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".rofl") {
+				return err
+			}
+			return add(p)
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if n == 0 && len(paths) > 0 {
+		return nil, 0, errors.New("no replay found")
+	}
+	return s, n, nil
+}
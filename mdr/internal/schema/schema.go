@@ -0,0 +1,126 @@
+// Package schema infers the shape of the participant stats stored in replay metadata.
+//
+// Every stat value is classified the way the metadata decoder reads it: numeric strings
+// count as numbers since FlexInt64 accepts both, and the types observed for a key across
+// participants and replays are merged into a set. The result drives the generation of
+// the StatsJSON struct and the comparison of replays from different patches.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Type is the set of JSON types observed for a stat.
+type Type uint8
+
+const (
+	TypeInteger Type = 1 << iota
+	TypeNumber
+	TypeString
+	TypeBool
+	TypeNull
+)
+
+var typeNames = []struct {
+	t    Type
+	name string
+}{
+	{TypeInteger, "integer"},
+	{TypeNumber, "number"},
+	{TypeString, "string"},
+	{TypeBool, "boolean"},
+	{TypeNull, "null"},
+}
+
+func (t Type) String() string {
+	var names []string
+	for _, n := range typeNames {
+		if t&n.t != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "unknown"
+	}
+	return strings.Join(names, "|")
+}
+
+// StringFields are the stats always read as strings, even when a value looks like a number
+// (e.g. a summoner called "1234").
+var StringFields = map[string]bool{
+	"WIN":                 true,
+	"NAME":                true,
+	"PUUID":               true,
+	"SKIN":                true,
+	"TEAM_POSITION":       true,
+	"INDIVIDUAL_POSITION": true,
+	"RIOT_ID_GAME_NAME":   true,
+}
+
+// Classify returns the type of one stat value.
+func Classify(key string, value json.RawMessage) Type {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return 0
+	}
+
+	switch value[0] {
+	case 'n':
+		return TypeNull
+	case 't', 'f':
+		return TypeBool
+	case '"':
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return TypeString
+		}
+		if StringFields[key] {
+			return TypeString
+		}
+		if s == "" {
+			// FlexInt64 reads an empty string as zero, it says nothing about the type
+			return TypeNull
+		}
+		return classifyNumber(s, TypeString)
+	default:
+		return classifyNumber(string(value), 0)
+	}
+}
+
+// classifyNumber tells integers and floats apart, returning otherwise when s is neither.
+func classifyNumber(s string, otherwise Type) Type {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return TypeInteger
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return TypeNumber
+	}
+	return otherwise
+}
+
+// Schema maps every stat key to the types observed for it.
+type Schema map[string]Type
+
+// Add records the stats of one participant.
+func (s Schema) Add(participant map[string]json.RawMessage) {
+	for key, value := range participant {
+		s[key] |= Classify(key, value)
+	}
+}
+
+// Merge adds the keys and types of o to s.
+func (s Schema) Merge(o Schema) {
+	for key, t := range o {
+		s[key] |= t
+	}
+}
+
+// Keys returns the keys of s, sorted.
+func (s Schema) Keys() []string {
+	return slices.Sorted(maps.Keys(s))
+}
@@ -1,39 +1,89 @@
+// Command mdr inspects League of Legends replay files (.rofl).
+//
+// Usage:
+//
+//	mdr <command> [flags] <arguments>
+//
+// The commands are:
+//
+//	info      print the header, sections and version of a replay
+//	metadata  print the metadata of a replay as JSON
+//	players   print the scoreboard of a replay
+//	verify    check that replays parse and that their chunks decode
+//	batch     summarize every replay of a directory
+//	export    write the participant stats of replays as CSV, TSV or Parquet
+//	index     index the metadata of the replays of directories in a SQLite database
+//
+// Every command accepts -format (text or json; csv, tsv or parquet for export), -o to write
+// to a file instead of stdout and -v to log the parsing stages to stderr. Parquet exports
+// are datasets, their -o names a directory. Flags may also follow the arguments.
+// Run "mdr <command> -h" for its own flags.
+//
+// The exit status is 0 on success, 1 when a replay could not be read or failed
+// verification and 2 on usage errors.
 package main
 
 import (
 	"fmt"
-	"log"
 	"os"
+	"slices"
+)
 
-	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+const (
+	exitOK    = 0
+	exitFail  = 1
+	exitUsage = 2
 )
 
-/// Example usage of the rofl package to open a ROFL file
-/// Make sure to replace the file path with an actual ROFL file path on your system
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string) int
+}
 
-func main() {
-	roflFile, err := rofl.OpenRoflFile("./test/replays/EUW1-7610660427.rofl")
-	if err != nil {
-		log.Fatalf("Error opening ROFL file: %v", err)
+var commands []command
+
+func init() {
+	// Set in init as the usage of every command refers back to the list
+	commands = []command{
+		{"info", "<file>", "print the header, sections and version of a replay", runInfo},
+		{"metadata", "<file>", "print the metadata of a replay as JSON", runMetadata},
+		{"players", "<file>", "print the scoreboard of a replay", runPlayers},
+		{"verify", "<file>...", "check that replays parse and that their chunks decode", runVerify},
+		{"batch", "<dir>", "summarize every replay of a directory", runBatch},
+		{"export", "<file or dir>...", "write the participant stats of replays as CSV, TSV or Parquet", runExport},
+		{"index", "<dir>...", "index the metadata of the replays of directories in a SQLite database", runIndex},
 	}
+}
 
-	fmt.Printf("ROFL file opened successfully: %s\n", roflFile.Path)
-	fmt.Printf("Metadata offset: %d\n", roflFile.MetadataOffset)
-	fmt.Printf("Bytes without metadata length: %d\n", len(roflFile.BytesWithoutMetadata))
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	f, err := os.Create("metadata-extracted.json")
-	if err != nil {
-		fmt.Println(err)
-		return
+func run(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage()
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
 	}
 
-	defer f.Close()
+	i := slices.IndexFunc(commands, func(c command) bool { return c.name == args[0] })
+	if i < 0 {
+		fmt.Fprintf(os.Stderr, "mdr: unknown command %q\n", args[0])
+		usage()
+		return exitUsage
+	}
+	return commands[i].run(args[1:])
+}
 
-	l, err := f.WriteString(roflFile.MetadataString)
-	if err != nil {
-		fmt.Println(err)
-		f.Close()
-		return
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: mdr <command> [flags] <arguments>")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", c.name, c.summary)
 	}
-	fmt.Println(l, "bytes written successfully")
 }
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+func runMetadata(args []string) int {
+	c := newCLI("metadata", "<file>", formatJSON)
+	if code, ok := c.parse(args, 1); !ok {
+		return code
+	}
+
+	f, err := rofl.OpenRoflFile(c.args[0], c.options()...)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	// MetadataString is already indented and keeps the stats StatsJSON has no field for
+	err = c.write(func(w io.Writer) error {
+		_, err := io.WriteString(w, f.MetadataString+"\n")
+		return err
+	})
+	if err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl/stats"
+)
+
+type playerRow struct {
+	ID                int    `json:"id"`
+	PUUID             string `json:"puuid,omitempty"`
+	RiotID            string `json:"riotId"`
+	Champion          string `json:"champion"`
+	Team              string `json:"team"`
+	Position          string `json:"position"`
+	Won               bool   `json:"won"`
+	Level             int    `json:"level"`
+	Kills             int    `json:"kills"`
+	Deaths            int    `json:"deaths"`
+	Assists           int    `json:"assists"`
+	CS                int64  `json:"cs"`
+	Gold              int64  `json:"gold"`
+	DamageToChampions int64  `json:"damageToChampions"`
+	VisionScore       int64  `json:"visionScore"`
+}
+
+func runPlayers(args []string) int {
+	c := newCLI("players", "<file>", formatText, formatJSON)
+	if code, ok := c.parse(args, 1); !ok {
+		return code
+	}
+
+	f, err := rofl.OpenRoflFile(c.args[0], c.options()...)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	// Conversion errors only affect the fields they name, the scoreboard is still worth printing
+	participants, err := f.Metadata.Participants()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mdr players: %v\n", err)
+	}
+	game := stats.Compute(&f.Metadata)
+
+	rows := make([]playerRow, len(participants))
+	for i, p := range participants {
+		s := p.Stats
+		rows[i] = playerRow{
+			ID:                p.ID,
+			PUUID:             p.PUUID,
+			RiotID:            riotID(p),
+			Champion:          p.Champion,
+			Team:              p.Team.String(),
+			Position:          p.TeamPosition.String(),
+			Won:               p.Won,
+			Level:             p.Level,
+			Kills:             p.Kills,
+			Deaths:            p.Deaths,
+			Assists:           p.Assists,
+			CS:                game.Participants[i].CS,
+			Gold:              int64(s.GoldEarned),
+			DamageToChampions: int64(s.TotalDamageDealtToChampions),
+			VisionScore:       int64(s.VisionScore),
+		}
+	}
+
+	err = c.write(func(w io.Writer) error {
+		if c.format == formatJSON {
+			return writeJSON(w, rows)
+		}
+		return writePlayers(w, rows)
+	})
+	if err != nil {
+		return c.fail(err)
+	}
+	return exitOK
+}
+
+func riotID(p rofl.Participant) string {
+	name := p.RiotIDGameName
+	if name == "" {
+		name = p.Name
+	}
+	if p.RiotIDTagLine == "" {
+		return name
+	}
+	return name + "#" + p.RiotIDTagLine
+}
+
+func writePlayers(w io.Writer, rows []playerRow) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEAM\tPOSITION\tCHAMPION\tPLAYER\tK/D/A\tCS\tGOLD\tDAMAGE\tVISION\tRESULT")
+	for _, r := range rows {
+		result := "loss"
+		if r.Won {
+			result = "win"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d/%d/%d\t%d\t%d\t%d\t%d\t%s\n",
+			r.Team, r.Position, r.Champion, r.RiotID, r.Kills, r.Deaths, r.Assists,
+			r.CS, r.Gold, r.DamageToChampions, r.VisionScore, result)
+	}
+	return tw.Flush()
+}
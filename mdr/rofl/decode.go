@@ -0,0 +1,239 @@
+package rofl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/crypto/blowfish"
+)
+
+// Codec identifies how a chunk or keyframe is encoded on disk.
+type Codec int
+
+const (
+	CodecUnknown Codec = iota
+	// CodecBlowfishGzip is the legacy scheme: gzip compressed, then Blowfish (ECB, PKCS#5) encrypted
+	// with a key derived from the payload header.
+	CodecBlowfishGzip
+	// CodecZstd is the newer scheme: a plain zstd frame, not encrypted.
+	CodecZstd
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecBlowfishGzip:
+		return "blowfish+gzip"
+	case CodecZstd:
+		return "zstd"
+	default:
+		return "unknown"
+	}
+}
+
+// KeyError is returned when a segment cannot be decrypted with the key of the replay.
+type KeyError struct {
+	GameID uint64
+	Err    error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("cannot decrypt segment of game %d: %v", e.GameID, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// CodecError is returned when the segment bytes do not match the codec they were decoded with.
+type CodecError struct {
+	Codec Codec
+	Err   error
+}
+
+func (e *CodecError) Error() string {
+	return fmt.Sprintf("cannot decode %s segment: %v", e.Codec, e.Err)
+}
+
+func (e *CodecError) Unwrap() error {
+	return e.Err
+}
+
+var zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+
+// DetectCodec guesses the codec of a raw segment. Zstd frames are recognised by their
+// magic number, anything else is assumed to be encrypted when the replay has a key.
+func DetectCodec(data []byte, payload PayloadHeader) Codec {
+	if bytes.HasPrefix(data, zstdMagic) {
+		return CodecZstd
+	}
+	if payload.EncryptionKey != "" {
+		return CodecBlowfishGzip
+	}
+	return CodecUnknown
+}
+
+// DecodeSegment turns a raw chunk or keyframe, as returned by ReadChunk or ReadKeyframe,
+// into its plaintext packet stream.
+func DecodeSegment(data []byte, payload PayloadHeader) ([]byte, error) {
+	switch codec := DetectCodec(data, payload); codec {
+	case CodecZstd:
+		return decodeZstd(data)
+	case CodecBlowfishGzip:
+		key, err := segmentKey(payload)
+		if err != nil {
+			return nil, err
+		}
+		return decodeBlowfishGzip(data, key, payload.GameID)
+	default:
+		return nil, &CodecError{Codec: codec, Err: errors.New("segment is neither a zstd frame nor encrypted")}
+	}
+}
+
+// segmentKey derives the key used to encrypt the segments: the base64 key stored in the
+// payload header, itself Blowfish encrypted with the game ID in decimal as key.
+func segmentKey(payload PayloadHeader) ([]byte, error) {
+	if payload.EncryptionKey == "" {
+		return nil, &KeyError{GameID: payload.GameID, Err: ErrMissingKey}
+	}
+
+	encrypted, err := base64.StdEncoding.DecodeString(payload.EncryptionKey)
+	if err != nil {
+		return nil, &KeyError{GameID: payload.GameID, Err: fmt.Errorf("key is not valid base64: %w", err)}
+	}
+
+	key, err := blowfishDecrypt(encrypted, []byte(strconv.FormatUint(payload.GameID, 10)))
+	if err != nil {
+		return nil, &KeyError{GameID: payload.GameID, Err: err}
+	}
+
+	return key, nil
+}
+
+func decodeBlowfishGzip(data, key []byte, gameID uint64) ([]byte, error) {
+	compressed, err := blowfishDecrypt(data, key)
+	if err != nil {
+		return nil, &KeyError{GameID: gameID, Err: err}
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, &CodecError{Codec: CodecBlowfishGzip, Err: err}
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, &CodecError{Codec: CodecBlowfishGzip, Err: err}
+	}
+
+	return out, nil
+}
+
+// blowfishDecrypt decrypts data in ECB mode and strips its PKCS#5 padding.
+func blowfishDecrypt(data, key []byte) ([]byte, error) {
+	c, err := blowfish.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 || len(data)%blowfish.BlockSize != 0 {
+		return nil, fmt.Errorf("encrypted length %d is not a multiple of the block size", len(data))
+	}
+
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += blowfish.BlockSize {
+		c.Decrypt(out[i:i+blowfish.BlockSize], data[i:i+blowfish.BlockSize])
+	}
+
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > blowfish.BlockSize {
+		return nil, ErrBadPadding
+	}
+	for _, b := range out[len(out)-pad:] {
+		if int(b) != pad {
+			return nil, ErrBadPadding
+		}
+	}
+
+	return out[:len(out)-pad], nil
+}
+
+// zstdDecoder is shared by every replay; DecodeAll is safe for concurrent use.
+var zstdDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+	return zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+})
+
+func decodeZstd(data []byte) ([]byte, error) {
+	dec, err := zstdDecoder()
+	if err != nil {
+		return nil, &CodecError{Codec: CodecZstd, Err: err}
+	}
+
+	out, err := dec.DecodeAll(data, nil)
+	if err != nil {
+		return nil, &CodecError{Codec: CodecZstd, Err: err}
+	}
+
+	return out, nil
+}
+
+// DecodeChunk reads the chunk with the given ID and returns its plaintext packet stream.
+func (r *RoflFile) DecodeChunk(id uint32) ([]byte, error) {
+	data, err := r.ReadChunk(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.decodeSegment(SegmentChunk, id, data)
+}
+
+// DecodeKeyframe reads the keyframe with the given ID and returns its plaintext packet stream.
+func (r *RoflFile) DecodeKeyframe(id uint32) ([]byte, error) {
+	data, err := r.ReadKeyframe(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.decodeSegment(SegmentKeyframe, id, data)
+}
+
+func (r *RoflFile) decodeSegment(t SegmentType, id uint32, data []byte) ([]byte, error) {
+	out, err := DecodeSegment(data, r.PayloadHeader)
+	if err != nil {
+		return nil, parseError(StageSegment, r.segmentOffset(t, id), fmt.Errorf("%s %d: %w", t, id, err))
+	}
+	r.logger.Debug("segment decoded",
+		slog.String("type", t.String()),
+		slog.Uint64("id", uint64(id)),
+		slog.String("codec", DetectCodec(data, r.PayloadHeader).String()),
+		slog.Int("raw_length", len(data)),
+		slog.Int("decoded_length", len(out)),
+	)
+	return out, nil
+}
+
+// segmentOffset returns the offset of an indexed segment, for error reporting.
+func (r *RoflFile) segmentOffset(t SegmentType, id uint32) int64 {
+	idx, err := r.SegmentIndex()
+	if err != nil {
+		return -1
+	}
+
+	var s Segment
+	var ok bool
+	if t == SegmentChunk {
+		s, ok = idx.Chunk(id)
+	} else {
+		s, ok = idx.Keyframe(id)
+	}
+	if !ok {
+		return -1
+	}
+	return int64(s.Offset)
+}
@@ -0,0 +1,218 @@
+package rofl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/crypto/blowfish"
+)
+
+// blowfishEncrypt pads data as PKCS#5 and encrypts it in ECB mode, the reverse of blowfishDecrypt.
+func blowfishEncrypt(t *testing.T, data, key []byte) []byte {
+	t.Helper()
+	c, err := blowfish.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := blowfish.BlockSize - len(data)%blowfish.BlockSize
+	out := append(slices.Clone(data), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	for i := 0; i < len(out); i += blowfish.BlockSize {
+		c.Encrypt(out[i:i+blowfish.BlockSize], out[i:i+blowfish.BlockSize])
+	}
+	return out
+}
+
+// encryptedPayload returns a payload header whose key decrypts to segmentKey,
+// stored as the replay stores it: encrypted with the game ID, then base64 encoded.
+func encryptedPayload(t *testing.T, gameID uint64, segmentKey []byte) PayloadHeader {
+	encrypted := blowfishEncrypt(t, segmentKey, []byte(strconv.FormatUint(gameID, 10)))
+	return PayloadHeader{GameID: gameID, EncryptionKey: base64.StdEncoding.EncodeToString(encrypted)}
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close()
+	return enc.EncodeAll(data, nil)
+}
+
+func TestDecodeSegment(t *testing.T) {
+	plaintext := []byte("packets of the chunk, long enough to span several Blowfish blocks")
+	key := []byte("segment-key-0123")
+	payload := encryptedPayload(t, 7610660427, key)
+
+	noKey := payload
+	noKey.EncryptionKey = ""
+	badBase64 := payload
+	badBase64.EncryptionKey = "not base64!"
+	otherGame := payload
+	otherGame.GameID = 7610660428
+
+	tests := []struct {
+		name     string
+		data     []byte
+		payload  PayloadHeader
+		want     []byte
+		wantErr  error
+		keyErr   bool
+		codecErr bool
+		codec    Codec
+	}{
+		{
+			name:    "blowfish and gzip",
+			data:    blowfishEncrypt(t, gzipBytes(t, plaintext), key),
+			payload: payload,
+			want:    plaintext,
+		},
+		{
+			name:    "zstd",
+			data:    zstdBytes(t, plaintext),
+			payload: noKey,
+			want:    plaintext,
+		},
+		{
+			// Zstd frames are recognised before the key is looked at
+			name:    "zstd in a replay with a key",
+			data:    zstdBytes(t, plaintext),
+			payload: payload,
+			want:    plaintext,
+		},
+		{
+			name:    "wrong segment key",
+			data:    blowfishEncrypt(t, gzipBytes(t, plaintext), []byte("another-key")),
+			payload: payload,
+			wantErr: ErrBadPadding,
+			keyErr:  true,
+		},
+		{
+			name:    "key encrypted for another game",
+			data:    blowfishEncrypt(t, gzipBytes(t, plaintext), key),
+			payload: otherGame,
+			wantErr: ErrBadPadding,
+			keyErr:  true,
+		},
+		{
+			name:    "key is not base64",
+			data:    blowfishEncrypt(t, gzipBytes(t, plaintext), key),
+			payload: badBase64,
+			keyErr:  true,
+		},
+		{
+			name:    "not a multiple of the block size",
+			data:    blowfishEncrypt(t, gzipBytes(t, plaintext), key)[:9],
+			payload: payload,
+			keyErr:  true,
+		},
+		{
+			name:     "decrypted segment is not gzip",
+			data:     blowfishEncrypt(t, plaintext, key),
+			payload:  payload,
+			codecErr: true,
+			codec:    CodecBlowfishGzip,
+		},
+		{
+			name:     "corrupted zstd frame",
+			data:     append(slices.Clone(zstdMagic), 0xFF, 0xFF, 0xFF, 0xFF),
+			payload:  noKey,
+			codecErr: true,
+			codec:    CodecZstd,
+		},
+		{
+			name:     "neither zstd nor encrypted",
+			data:     plaintext,
+			payload:  noKey,
+			codecErr: true,
+			codec:    CodecUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeSegment(tt.data, tt.payload)
+			if tt.wantErr == nil && !tt.keyErr && !tt.codecErr {
+				if err != nil {
+					t.Fatalf("DecodeSegment() error = %v", err)
+				}
+				if !bytes.Equal(got, tt.want) {
+					t.Errorf("DecodeSegment() = %q, want %q", got, tt.want)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("DecodeSegment() = %q, want an error", got)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("DecodeSegment() error = %v, want %v", err, tt.wantErr)
+			}
+			var kerr *KeyError
+			if tt.keyErr && (!errors.As(err, &kerr) || kerr.GameID != tt.payload.GameID) {
+				t.Errorf("DecodeSegment() error = %#v, want a *KeyError of game %d", err, tt.payload.GameID)
+			}
+			var cerr *CodecError
+			if tt.codecErr && (!errors.As(err, &cerr) || cerr.Codec != tt.codec) {
+				t.Errorf("DecodeSegment() error = %#v, want a *CodecError of codec %s", err, tt.codec)
+			}
+		})
+	}
+}
+
+func TestSegmentKey(t *testing.T) {
+	key := []byte("segment-key-0123")
+	payload := encryptedPayload(t, 42, key)
+
+	got, err := segmentKey(payload)
+	if err != nil || !bytes.Equal(got, key) {
+		t.Errorf("segmentKey() = %q, %v, want %q", got, err, key)
+	}
+
+	_, err = segmentKey(PayloadHeader{GameID: 42})
+	var kerr *KeyError
+	if !errors.Is(err, ErrMissingKey) || !errors.As(err, &kerr) || kerr.GameID != 42 {
+		t.Errorf("segmentKey() without a key error = %#v, want a *KeyError wrapping %v", err, ErrMissingKey)
+	}
+}
+
+func TestDecodeChunk(t *testing.T) {
+	plaintext := []byte("packets")
+	payload := slices.Concat(
+		trailerSegment(1, SegmentChunk, string(zstdBytes(t, plaintext))),
+		trailerSegment(2, SegmentChunk, "neither zstd nor encrypted"),
+	)
+	f, err := ParseRofl(trailerFile("15.23.726.9074", payload, []byte(testMetadata)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := f.DecodeChunk(1); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("DecodeChunk(1) = %q, %v, want %q", got, err, plaintext)
+	}
+
+	_, err = f.DecodeChunk(2)
+	var perr *ParseError
+	var cerr *CodecError
+	if !errors.As(err, &perr) || perr.Stage != StageSegment || !errors.As(err, &cerr) {
+		t.Errorf("DecodeChunk(2) error = %#v, want a *ParseError of stage %q wrapping a *CodecError", err, StageSegment)
+	}
+}
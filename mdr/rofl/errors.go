@@ -0,0 +1,70 @@
+package rofl
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors describing why a replay could not be parsed.
+// Parsing functions wrap them, usually in a *ParseError, test them with errors.Is.
+var (
+	// ErrNotRofl is returned when the file does not start with the ROFL magic bytes.
+	ErrNotRofl = errors.New("file is not a valid ROFL file")
+	// ErrTruncated is returned when the file ends before a section it announces,
+	// typically an interrupted download.
+	ErrTruncated = errors.New("file is truncated")
+	// ErrCorrupted is returned when a section is present but its content is invalid.
+	ErrCorrupted = errors.New("file is corrupted")
+	// ErrUnsupportedVersion is returned when the file layout is not one this package knows how to read.
+	ErrUnsupportedVersion = errors.New("unsupported ROFL layout")
+	// ErrMetadataNotFound is returned, along with ErrUnsupportedVersion, when the metadata
+	// could not be located even by scanning the file.
+	ErrMetadataNotFound = errors.New("metadata offset not found")
+	// ErrSegmentNotFound is returned when a chunk or keyframe ID is not in the segment index.
+	ErrSegmentNotFound = errors.New("segment not found")
+	// ErrInconsistentWin is returned when the WIN stats do not designate exactly one winning team.
+	ErrInconsistentWin = errors.New("inconsistent WIN stats")
+	// ErrWrongGameMode is returned by the views specific to a game mode, e.g. ArenaStandings,
+	// when the replay was played in another mode.
+	ErrWrongGameMode = errors.New("wrong game mode")
+
+	// ErrMissingKey is returned when a segment needs decrypting but the payload header has no key.
+	ErrMissingKey = errors.New("replay has no encryption key")
+	// ErrBadPadding is returned when decrypted data does not end with valid PKCS#5 padding,
+	// which almost always means the key is wrong.
+	ErrBadPadding = errors.New("invalid padding after decryption")
+)
+
+// Stage is the parsing step an error happened in.
+type Stage string
+
+const (
+	StageHeader        Stage = "header"
+	StageMetadata      Stage = "metadata"
+	StagePayloadHeader Stage = "payload header"
+	StageSegmentIndex  Stage = "segment index"
+	StageSegment       Stage = "segment"
+)
+
+// ParseError carries the stage and byte offset at which parsing failed.
+// Offset is -1 when the failure is not tied to a position in the file.
+type ParseError struct {
+	Stage  Stage
+	Offset int64
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("rofl: %s: %v", e.Stage, e.Err)
+	}
+	return fmt.Sprintf("rofl: %s at offset %d: %v", e.Stage, e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func parseError(stage Stage, offset int64, err error) error {
+	return &ParseError{Stage: stage, Offset: offset, Err: err}
+}
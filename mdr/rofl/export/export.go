@@ -0,0 +1,166 @@
+// Package export flattens the participant stats of replays into CSV or TSV rows,
+// one row per participant, for use in spreadsheets, or into Parquet tables for
+// larger corpora (see ParquetWriter).
+//
+// Columns are named after the stat keys as written in the metadata (e.g. "GOLD_EARNED"),
+// preceded by the game columns below. The columns are fixed when the Writer is created,
+// so that every replay written to it lines up whatever keys its patch has: a stat missing
+// from a replay is left empty, and keys absent from the column list are dropped.
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+// Game columns, filled from the replay rather than from the participant stats.
+const (
+	ColumnGameID     = "gameId"
+	ColumnGameLength = "gameLength"
+	ColumnPatch      = "patch"
+)
+
+// GameColumns are the game columns, in the order DefaultColumns puts them.
+var GameColumns = []string{ColumnGameID, ColumnGameLength, ColumnPatch}
+
+// DefaultColumns returns the game columns followed by every stat StatsJSON has a field for.
+// Stats newer than the struct are only exported when asked for with WithColumns.
+func DefaultColumns() []string {
+	return slices.Concat(GameColumns, rofl.StatKeys())
+}
+
+// Option configures a Writer or a ParquetWriter.
+type Option func(*options)
+
+type options struct {
+	columns        []string
+	comma          rune
+	rowGroupSize   int
+	maxRowsPerFile int64
+}
+
+// WithColumns selects the columns to write, in this order. Game columns and stat keys
+// can be mixed; keys StatsJSON has no field for are read with StatsJSON.StatString.
+func WithColumns(columns ...string) Option {
+	return func(o *options) {
+		if len(columns) > 0 {
+			o.columns = slices.Clone(columns)
+		}
+	}
+}
+
+// WithComma sets the field delimiter, ',' by default. Use '\t' for TSV.
+func WithComma(r rune) Option {
+	return func(o *options) {
+		o.comma = r
+	}
+}
+
+// WithRowGroupSize sets the number of rows of a Parquet row group, 16384 by default.
+// Rows are buffered in memory until a row group is complete.
+func WithRowGroupSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.rowGroupSize = n
+		}
+	}
+}
+
+// WithMaxRowsPerFile sets the number of rows after which a Parquet file is closed
+// and the next part of its partition started, 1048576 by default.
+func WithMaxRowsPerFile(n int64) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxRowsPerFile = n
+		}
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{comma: ',', rowGroupSize: 16384, maxRowsPerFile: 1 << 20}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Writer writes the participants of replays as rows. The header row is written
+// before the first replay, or by Flush when there was none.
+type Writer struct {
+	w       *csv.Writer
+	columns []string
+	header  bool
+}
+
+// NewWriter returns a Writer writing to w.
+func NewWriter(w io.Writer, opts ...Option) *Writer {
+	o := newOptions(opts)
+	if o.columns == nil {
+		o.columns = DefaultColumns()
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = o.comma
+	return &Writer{w: cw, columns: o.columns}
+}
+
+// Columns returns the columns the Writer writes.
+func (w *Writer) Columns() []string {
+	return slices.Clone(w.columns)
+}
+
+// Write writes one row per participant of the replay.
+func (w *Writer) Write(f *rofl.RoflFile) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	game := map[string]string{
+		ColumnGameLength: strconv.FormatInt(int64(f.Metadata.GameLength), 10),
+	}
+	if id := f.PayloadHeader.GameID; id != 0 {
+		game[ColumnGameID] = strconv.FormatUint(id, 10)
+	}
+	if !f.GameVersion.IsZero() {
+		game[ColumnPatch] = f.GameVersion.Patch()
+	}
+
+	record := make([]string, len(w.columns))
+	for i := range f.Metadata.StatsJSON {
+		s := &f.Metadata.StatsJSON[i]
+		for j, col := range w.columns {
+			if v, ok := game[col]; ok {
+				record[j] = v
+			} else if v, ok := s.StatString(col); ok {
+				record[j] = v
+			} else {
+				record[j] = ""
+			}
+		}
+		if err := w.w.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.w.Error()
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (w *Writer) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *Writer) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return w.w.Write(w.columns)
+}
@@ -0,0 +1,336 @@
+package export
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ZiedYousfi/analolzer/mdr/internal/parquet"
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+// Tables written by a ParquetWriter, each in its own directory.
+const (
+	// TableGames holds one row per replay, keyed by gameId.
+	TableGames = "games"
+	// TableParticipants holds one row per participant, gameId referring to the games table.
+	TableParticipants = "participants"
+)
+
+// ColumnParticipantID is the participant column numbering the participants of a game from 1.
+const ColumnParticipantID = "participantId"
+
+var gameColumns = []parquet.Column{
+	{Name: ColumnGameID, Kind: parquet.KindInt64},
+	{Name: "platform", Kind: parquet.KindString},
+	{Name: "matchId", Kind: parquet.KindString},
+	{Name: "gameVersion", Kind: parquet.KindString},
+	{Name: ColumnGameLength, Kind: parquet.KindInt64},
+	{Name: "gameMode", Kind: parquet.KindString},
+	{Name: "winningTeam", Kind: parquet.KindInt64},
+	{Name: "participants", Kind: parquet.KindInt64},
+}
+
+// statColumns are the columns of the StatsJSON fields: int64 for FlexInt64,
+// double for FlexFloat64 and string for everything else.
+var statColumns = sync.OnceValue(func() []parquet.Column {
+	t := reflect.TypeFor[rofl.StatsJSON]()
+	var columns []parquet.Column
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		kind := parquet.KindString
+		switch f.Type {
+		case reflect.TypeFor[rofl.FlexInt64]():
+			kind = parquet.KindInt64
+		case reflect.TypeFor[rofl.FlexFloat64]():
+			kind = parquet.KindDouble
+		}
+		columns = append(columns, parquet.Column{Name: name, Kind: kind})
+	}
+	return columns
+})
+
+// ParquetWriter writes replays as a Parquet dataset of two tables, games and participants,
+// partitioned by patch the way Hive, Spark, DuckDB and Arrow expect:
+//
+//	dir/games/patch=15.23/part-00000.parquet
+//	dir/participants/patch=15.23/part-00000.parquet
+//
+// The patch is not repeated as a column, readers take it from the directory name.
+// A partition is split in several parts once a file reaches the maximum number of rows.
+//
+// The participant columns are gameId and participantId followed by the stats: the
+// StatsJSON fields, typed after the field, then the stats StatsJSON has no field for,
+// typed after the values seen (int64, double or string). When a replay brings such a
+// stat that the open file does not have, or a value its column type can not hold, the
+// file is closed and the next part starts with the extended schema. Parts of a partition
+// may therefore have different columns; read them with schema merging enabled
+// (union_by_name in DuckDB, mergeSchema in Spark).
+//
+// WithColumns restricts the stat columns to the given keys. A ParquetWriter is not safe
+// for concurrent use.
+type ParquetWriter struct {
+	dir  string
+	opts options
+	// columns are the participant columns every part has.
+	columns    []parquet.Column
+	selected   map[string]bool // nil when every stat is written
+	partitions map[string]*partition
+}
+
+type partition struct {
+	games        table
+	participants table
+	// extra holds the columns of the stats StatsJSON has no field for seen so far, sorted.
+	extra []parquet.Column
+}
+
+// table is the open part of a table in a partition.
+type table struct {
+	dir  string
+	part int
+	file *os.File
+	w    *parquet.Writer
+}
+
+// NewParquetWriter returns a ParquetWriter writing under dir. Existing parts are
+// never overwritten: writing a part that already exists fails.
+func NewParquetWriter(dir string, opts ...Option) *ParquetWriter {
+	w := &ParquetWriter{
+		dir:        dir,
+		opts:       newOptions(opts),
+		partitions: map[string]*partition{},
+	}
+	w.columns = []parquet.Column{
+		{Name: ColumnGameID, Kind: parquet.KindInt64},
+		{Name: ColumnParticipantID, Kind: parquet.KindInt64},
+	}
+	if w.opts.columns == nil {
+		w.columns = append(w.columns, statColumns()...)
+		return w
+	}
+	w.selected = map[string]bool{}
+	for _, name := range w.opts.columns {
+		w.selected[name] = true
+	}
+	for _, c := range statColumns() {
+		if w.selected[c.Name] {
+			w.columns = append(w.columns, c)
+		}
+	}
+	return w
+}
+
+// Write adds the replay to the games table and its participants to the participants table.
+func (w *ParquetWriter) Write(f *rofl.RoflFile) error {
+	patch := "unknown"
+	if !f.GameVersion.IsZero() {
+		patch = f.GameVersion.Patch()
+	}
+	p, ok := w.partitions[patch]
+	if !ok {
+		partDir := "patch=" + patch
+		p = &partition{
+			games:        table{dir: filepath.Join(w.dir, TableGames, partDir)},
+			participants: table{dir: filepath.Join(w.dir, TableParticipants, partDir)},
+		}
+		w.partitions[patch] = p
+	}
+
+	var gameID any
+	if f.PayloadHeader.GameID != 0 {
+		gameID = int64(f.PayloadHeader.GameID)
+	}
+
+	if err := w.roll(&p.games, gameColumns, false); err != nil {
+		return err
+	}
+	var version, winner any
+	if !f.GameVersion.IsZero() {
+		version = f.GameVersion.String()
+	}
+	if team, err := f.Metadata.Winner(); err == nil && team != rofl.TeamUnknown {
+		winner = int64(team)
+	}
+	err := p.games.w.WriteRow([]any{
+		gameID,
+		nullString(f.PayloadHeader.Platform),
+		nullString(f.PayloadHeader.MatchID()),
+		version,
+		int64(f.Metadata.GameLength),
+		f.Metadata.GameMode().String(),
+		winner,
+		int64(len(f.Metadata.StatsJSON)),
+	})
+	if err != nil {
+		return err
+	}
+
+	changed := w.observe(p, &f.Metadata)
+	columns := slices.Concat(w.columns, p.extra)
+	if err := w.roll(&p.participants, columns, changed); err != nil {
+		return err
+	}
+	row := make([]any, len(columns))
+	for i := range f.Metadata.StatsJSON {
+		s := &f.Metadata.StatsJSON[i]
+		row[0], row[1] = gameID, int64(i+1)
+		for j := 2; j < len(columns); j++ {
+			row[j] = statValue(s, columns[j])
+		}
+		if err := p.participants.w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close writes the footers of the open files and closes them.
+func (w *ParquetWriter) Close() error {
+	var errs []error
+	for _, p := range w.partitions {
+		errs = append(errs, p.games.close(), p.participants.close())
+	}
+	return errors.Join(errs...)
+}
+
+// roll makes sure t has an open part with room left, starting a new one
+// with the given columns when it is full or when force is set.
+func (w *ParquetWriter) roll(t *table, columns []parquet.Column, force bool) error {
+	if t.w != nil && !force && t.w.Rows() < w.opts.maxRowsPerFile {
+		return nil
+	}
+	if err := t.close(); err != nil {
+		return err
+	}
+	return t.open(columns, parquet.WithRowGroupSize(w.opts.rowGroupSize))
+}
+
+// observe adds to the partition the stats of m StatsJSON has no field for, widening the
+// type of the ones it already has when needed. It reports whether the columns changed.
+func (w *ParquetWriter) observe(p *partition, m *rofl.Metadata) bool {
+	changed := false
+	for i := range m.StatsJSON {
+		s := &m.StatsJSON[i]
+		for _, key := range s.UnknownKeys() {
+			if w.selected != nil && !w.selected[key] {
+				continue
+			}
+			kind, ok := statKind(s, key)
+			if !ok {
+				continue
+			}
+			j := slices.IndexFunc(p.extra, func(c parquet.Column) bool { return c.Name == key })
+			if j < 0 {
+				p.extra = append(p.extra, parquet.Column{Name: key, Kind: kind})
+				changed = true
+				continue
+			}
+			if widened := widen(p.extra[j].Kind, kind); widened != p.extra[j].Kind {
+				p.extra[j].Kind = widened
+				changed = true
+			}
+		}
+	}
+	if changed {
+		slices.SortFunc(p.extra, func(a, b parquet.Column) int { return cmp.Compare(a.Name, b.Name) })
+	}
+	return changed
+}
+
+// statKind returns the column type a stat value fits in, ok being false for nulls
+// and empty strings as they say nothing about the type.
+func statKind(s *rofl.StatsJSON, key string) (kind parquet.Kind, ok bool) {
+	v, ok := s.StatString(key)
+	if !ok || v == "" {
+		return 0, false
+	}
+	if _, ok := s.Stat(key); ok {
+		return parquet.KindInt64, true
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return parquet.KindDouble, true
+	}
+	return parquet.KindString, true
+}
+
+// widen returns the type of a column holding values of both types.
+func widen(a, b parquet.Kind) parquet.Kind {
+	switch {
+	case a == b:
+		return a
+	case a == parquet.KindString || b == parquet.KindString:
+		return parquet.KindString
+	default:
+		return parquet.KindDouble
+	}
+}
+
+func statValue(s *rofl.StatsJSON, c parquet.Column) any {
+	switch c.Kind {
+	case parquet.KindInt64:
+		if v, ok := s.Stat(c.Name); ok {
+			return v
+		}
+	case parquet.KindDouble:
+		if v, ok := s.StatString(c.Name); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+	case parquet.KindString:
+		if v, ok := s.StatString(c.Name); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func (t *table) open(columns []parquet.Column, opts ...parquet.Option) error {
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+	name := filepath.Join(t.dir, fmt.Sprintf("part-%05d.parquet", t.part))
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	pw, err := parquet.NewWriter(file, columns, opts...)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	t.part++
+	t.file, t.w = file, pw
+	return nil
+}
+
+func (t *table) close() error {
+	if t.w == nil {
+		return nil
+	}
+	err := t.w.Close()
+	if cerr := t.file.Close(); err == nil {
+		err = cerr
+	}
+	t.file, t.w = nil, nil
+	return err
+}
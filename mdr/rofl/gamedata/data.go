@@ -0,0 +1,260 @@
+package gamedata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+// Item is an item of the shop.
+type Item struct {
+	ID          rofl.ItemID
+	Name        string
+	Description string
+	Icon        string
+	// Cost is the total cost of the item, components included.
+	Cost      int
+	SellValue int
+	Tags      []string
+}
+
+// Champion is a champion, keyed in replays by its ID (the SKIN stat).
+type Champion struct {
+	// ID is the internal name of the champion, e.g. "MonkeyKing".
+	ID string
+	// Key is the numeric ID of the champion.
+	Key   int
+	Name  string
+	Title string
+	Icon  string
+	Tags  []string
+}
+
+// Rune is a rune or a rune path (style).
+type Rune struct {
+	ID   rofl.RuneID
+	Key  string
+	Name string
+	Icon string
+	// Style is the path the rune belongs to, zero for the paths themselves.
+	Style rofl.RuneID
+	// Keystone is set for the runes of the first row of a path.
+	Keystone  bool
+	ShortDesc string
+}
+
+// SummonerSpell is a summoner spell.
+type SummonerSpell struct {
+	ID       rofl.SummonerSpellID
+	Key      string
+	Name     string
+	Icon     string
+	Cooldown float64
+}
+
+// Data is the static data of one patch.
+type Data struct {
+	// Version is the Data Dragon version of the snapshot, e.g. "15.23.1".
+	Version string
+
+	items     map[rofl.ItemID]Item
+	champions map[string]Champion
+	runes     map[rofl.RuneID]Rune
+	spells    map[rofl.SummonerSpellID]SummonerSpell
+}
+
+// Item returns the item with the given ID.
+func (d *Data) Item(id rofl.ItemID) (Item, bool) {
+	it, ok := d.items[id]
+	return it, ok
+}
+
+// Champion returns the champion with the given ID, as found in the SKIN stat.
+func (d *Data) Champion(id string) (Champion, bool) {
+	c, ok := d.champions[id]
+	return c, ok
+}
+
+// Rune returns the rune or rune path with the given ID. Stat shards (STAT_PERK_*)
+// are not part of Data Dragon and are not found.
+func (d *Data) Rune(id rofl.RuneID) (Rune, bool) {
+	r, ok := d.runes[id]
+	return r, ok
+}
+
+// SummonerSpell returns the summoner spell with the given ID.
+func (d *Data) SummonerSpell(id rofl.SummonerSpellID) (SummonerSpell, bool) {
+	s, ok := d.spells[id]
+	return s, ok
+}
+
+// ddImage is the image object of the Data Dragon files.
+type ddImage struct {
+	Full  string `json:"full"`
+	Group string `json:"group"`
+}
+
+func (img ddImage) path(dir string) string {
+	if img.Full == "" {
+		return ""
+	}
+	return path.Join(dir, "img", img.Group, img.Full)
+}
+
+func loadData(fsys fs.FS, dir, locale string) (*Data, error) {
+	d := &Data{Version: dir}
+	dataDir := path.Join(dir, "data", locale)
+
+	var items struct {
+		Data map[string]struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Gold        struct {
+				Total int `json:"total"`
+				Sell  int `json:"sell"`
+			} `json:"gold"`
+			Tags  []string `json:"tags"`
+			Image ddImage  `json:"image"`
+		} `json:"data"`
+	}
+	if err := readJSON(fsys, path.Join(dataDir, "item.json"), &items); err != nil {
+		return nil, err
+	}
+	d.items = make(map[rofl.ItemID]Item, len(items.Data))
+	for key, it := range items.Data {
+		id, err := strconv.ParseInt(key, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("item.json: invalid item ID %q", key)
+		}
+		d.items[rofl.ItemID(id)] = Item{
+			ID:          rofl.ItemID(id),
+			Name:        it.Name,
+			Description: it.Description,
+			Icon:        it.Image.path(dir),
+			Cost:        it.Gold.Total,
+			SellValue:   it.Gold.Sell,
+			Tags:        it.Tags,
+		}
+	}
+
+	var champions struct {
+		Data map[string]struct {
+			ID    string   `json:"id"`
+			Key   string   `json:"key"`
+			Name  string   `json:"name"`
+			Title string   `json:"title"`
+			Tags  []string `json:"tags"`
+			Image ddImage  `json:"image"`
+		} `json:"data"`
+	}
+	if err := readJSON(fsys, path.Join(dataDir, "champion.json"), &champions); err != nil {
+		return nil, err
+	}
+	d.champions = make(map[string]Champion, len(champions.Data))
+	for _, c := range champions.Data {
+		key, err := strconv.Atoi(c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("champion.json: invalid key %q for %s", c.Key, c.ID)
+		}
+		d.champions[c.ID] = Champion{
+			ID:    c.ID,
+			Key:   key,
+			Name:  c.Name,
+			Title: c.Title,
+			Icon:  c.Image.path(dir),
+			Tags:  c.Tags,
+		}
+	}
+
+	type ddRune struct {
+		ID        int    `json:"id"`
+		Key       string `json:"key"`
+		Name      string `json:"name"`
+		Icon      string `json:"icon"`
+		ShortDesc string `json:"shortDesc"`
+	}
+	var styles []struct {
+		ddRune
+		Slots []struct {
+			Runes []ddRune `json:"runes"`
+		} `json:"slots"`
+	}
+	if err := readJSON(fsys, path.Join(dataDir, "runesReforged.json"), &styles); err != nil {
+		return nil, err
+	}
+	d.runes = map[rofl.RuneID]Rune{}
+	icon := func(p string) string {
+		if p == "" {
+			return ""
+		}
+		return path.Join(dir, "img", p)
+	}
+	for _, style := range styles {
+		d.runes[rofl.RuneID(style.ID)] = Rune{
+			ID:   rofl.RuneID(style.ID),
+			Key:  style.Key,
+			Name: style.Name,
+			Icon: icon(style.Icon),
+		}
+		for row, slot := range style.Slots {
+			for _, r := range slot.Runes {
+				d.runes[rofl.RuneID(r.ID)] = Rune{
+					ID:        rofl.RuneID(r.ID),
+					Key:       r.Key,
+					Name:      r.Name,
+					Icon:      icon(r.Icon),
+					Style:     rofl.RuneID(style.ID),
+					Keystone:  row == 0,
+					ShortDesc: r.ShortDesc,
+				}
+			}
+		}
+	}
+
+	var spells struct {
+		Data map[string]struct {
+			ID       string    `json:"id"`
+			Key      string    `json:"key"`
+			Name     string    `json:"name"`
+			Cooldown []float64 `json:"cooldown"`
+			Image    ddImage   `json:"image"`
+		} `json:"data"`
+	}
+	if err := readJSON(fsys, path.Join(dataDir, "summoner.json"), &spells); err != nil {
+		return nil, err
+	}
+	d.spells = make(map[rofl.SummonerSpellID]SummonerSpell, len(spells.Data))
+	for _, s := range spells.Data {
+		id, err := strconv.ParseInt(s.Key, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("summoner.json: invalid key %q for %s", s.Key, s.ID)
+		}
+		spell := SummonerSpell{
+			ID:   rofl.SummonerSpellID(id),
+			Key:  s.ID,
+			Name: s.Name,
+			Icon: s.Image.path(dir),
+		}
+		if len(s.Cooldown) > 0 {
+			spell.Cooldown = s.Cooldown[0]
+		}
+		d.spells[spell.ID] = spell
+	}
+
+	return d, nil
+}
+
+func readJSON(fsys fs.FS, name string, v any) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path.Base(name), err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Package gamedata resolves the numeric IDs found in replay stats (items, runes,
+// summoner spells) and the champion names to their static data, from a local copy
+// of Data Dragon. Nothing is ever fetched from the network.
+//
+// The snapshot directory holds one Data Dragon release per patch, laid out as in the
+// dragontail archives Riot publishes:
+//
+//	snapshots/
+//	    15.22.1/
+//	        data/en_US/champion.json
+//	        data/en_US/item.json
+//	        data/en_US/runesReforged.json
+//	        data/en_US/summoner.json
+//	        img/...
+//	    15.23.1/
+//	        ...
+//
+// Directories whose name is not a version are ignored. Icon paths are returned relative
+// to the snapshot directory, e.g. "15.23.1/img/item/1001.png". Rune icons, which dragontail
+// stores outside of the version directory, are expected under it as well
+// (15.23.1/img/perk-images/...).
+package gamedata
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+// ErrNoSnapshot is returned when no snapshot applies to a patch.
+var ErrNoSnapshot = errors.New("no game data snapshot for this patch")
+
+// Option configures a Store.
+type Option func(*options)
+
+type options struct {
+	locale string
+}
+
+// WithLocale selects the language of the names and descriptions, "en_US" by default.
+func WithLocale(locale string) Option {
+	return func(o *options) {
+		if locale != "" {
+			o.locale = locale
+		}
+	}
+}
+
+type snapshot struct {
+	version rofl.GameVersion
+	dir     string
+}
+
+// Store gives access to the snapshots of a directory. Snapshots are loaded on first use
+// and kept in memory; a Store is safe for concurrent use.
+type Store struct {
+	fsys      fs.FS
+	locale    string
+	snapshots []snapshot // sorted by version
+
+	mu     sync.Mutex
+	loaded map[string]*Data
+}
+
+// Open returns the store of the snapshots in dir.
+func Open(dir string, opts ...Option) (*Store, error) {
+	return OpenFS(os.DirFS(dir), opts...)
+}
+
+// OpenFS returns the store of the snapshots at the root of fsys.
+func OpenFS(fsys fs.FS, opts ...Option) (*Store, error) {
+	o := options{locale: "en_US"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{fsys: fsys, locale: o.locale, loaded: map[string]*Data{}}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		v, err := rofl.ParseGameVersion(e.Name())
+		if err != nil {
+			continue
+		}
+		s.snapshots = append(s.snapshots, snapshot{version: v, dir: e.Name()})
+	}
+	if len(s.snapshots) == 0 {
+		return nil, fmt.Errorf("%w: no snapshot directory found", ErrNoSnapshot)
+	}
+
+	slices.SortFunc(s.snapshots, func(a, b snapshot) int { return a.version.Compare(b.version) })
+	return s, nil
+}
+
+// Versions returns the versions of the snapshots available, oldest first.
+func (s *Store) Versions() []string {
+	versions := make([]string, len(s.snapshots))
+	for i, snap := range s.snapshots {
+		versions[i] = snap.dir
+	}
+	return versions
+}
+
+// ForVersion returns the data of the patch of v: the latest snapshot of the same patch,
+// or failing that the latest snapshot of an older patch. Data Dragon releases only follow
+// the patch number, the build and revision of v are ignored.
+func (s *Store) ForVersion(v rofl.GameVersion) (*Data, error) {
+	if v.IsZero() {
+		return nil, fmt.Errorf("%w: unknown game version", ErrNoSnapshot)
+	}
+
+	patch := rofl.GameVersion{Major: v.Major, Minor: v.Minor}
+	for _, snap := range slices.Backward(s.snapshots) {
+		snapPatch := rofl.GameVersion{Major: snap.version.Major, Minor: snap.version.Minor}
+		if snapPatch.Compare(patch) <= 0 {
+			return s.load(snap)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s is older than every snapshot", ErrNoSnapshot, v.Patch())
+}
+
+// ForReplay returns the data of the patch the replay was recorded with.
+func (s *Store) ForReplay(f *rofl.RoflFile) (*Data, error) {
+	return s.ForVersion(f.GameVersion)
+}
+
+func (s *Store) load(snap snapshot) (*Data, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d, ok := s.loaded[snap.dir]; ok {
+		return d, nil
+	}
+	d, err := loadData(s.fsys, snap.dir, s.locale)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", snap.dir, err)
+	}
+	s.loaded[snap.dir] = d
+	return d, nil
+}
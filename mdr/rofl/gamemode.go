@@ -0,0 +1,149 @@
+package rofl
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// GameMode is the mode a replay was played in, as far as the metadata tells.
+type GameMode int
+
+const (
+	GameModeUnknown GameMode = iota
+	// GameModeClassic is Summoner's Rift: draft, ranked and blind pick.
+	GameModeClassic
+	GameModeARAM
+	// GameModeArena is the 2v2v2v2 mode, see ArenaStandings.
+	GameModeArena
+	// GameModeSwarm is the PvE mode, every participant is on the same team.
+	GameModeSwarm
+	// GameModeEvent covers the rotating modes that have their own stats, such as
+	// node capture modes or Brawl.
+	GameModeEvent
+)
+
+func (g GameMode) String() string {
+	switch g {
+	case GameModeClassic:
+		return "Summoner's Rift"
+	case GameModeARAM:
+		return "ARAM"
+	case GameModeArena:
+		return "Arena"
+	case GameModeSwarm:
+		return "Swarm"
+	case GameModeEvent:
+		return "event"
+	default:
+		return "unknown"
+	}
+}
+
+// GameMode classifies the game from the stats of its participants. The metadata does not
+// store the mode, so it is inferred from the stats only some modes fill: subteams for
+// Arena, a single team for Swarm, node captures or event counters for event modes,
+// positions and jungle camps for Summoner's Rift. Stats of the other modes are left at zero
+// and must not be read as such, e.g. PLAYER_SUBTEAM_PLACEMENT outside of Arena.
+func (m *Metadata) GameMode() GameMode {
+	if len(m.StatsJSON) == 0 {
+		return GameModeUnknown
+	}
+
+	var (
+		teams                      = map[Team]bool{}
+		subteams, nodes, brawl     bool
+		aramEvents, positions, jgl bool
+	)
+	for i := range m.StatsJSON {
+		s := &m.StatsJSON[i]
+		teams[Team(s.Team)] = true
+		subteams = subteams || s.PlayerSubteam != 0 || s.PlayerSubteamPlacement != 0
+		nodes = nodes || s.NodeCapture != 0 || s.NodeNeutralize != 0
+		brawl = brawl || s.EventBrawlJungle != 0 || s.EventBrawlMinions != 0
+		aramEvents = aramEvents || s.EventARAMDocks != 0 || s.EventARAMHexgates != 0
+		positions = positions || (s.TeamPosition != "" && s.TeamPosition != "Invalid")
+		jgl = jgl || s.NeutralMinionsKilled != 0 || s.DragonKills != 0 || s.BaronKills != 0
+	}
+
+	switch {
+	case subteams:
+		return GameModeArena
+	case len(teams) == 1:
+		return GameModeSwarm
+	case nodes, brawl:
+		return GameModeEvent
+	case aramEvents, !positions && !jgl:
+		return GameModeARAM
+	case positions, jgl:
+		return GameModeClassic
+	default:
+		return GameModeUnknown
+	}
+}
+
+// AugmentID identifies an Arena augment.
+type AugmentID int32
+
+// ArenaPlayer is a participant of an Arena game.
+type ArenaPlayer struct {
+	// Participant indexes Metadata.StatsJSON.
+	Participant int
+	// Augments are the augments picked, in order, without the empty slots.
+	Augments []AugmentID
+}
+
+// ArenaStanding is a subteam of an Arena game and where it finished.
+type ArenaStanding struct {
+	Subteam int
+	// Placement is 1 for the winners. It is 0 when the metadata does not tell.
+	Placement int
+	Players   []ArenaPlayer
+}
+
+// ArenaStandings returns the subteams of an Arena game ordered by placement.
+// It fails with ErrWrongGameMode for the other modes, whose subteam stats are all zero.
+func (m *Metadata) ArenaStandings() ([]ArenaStanding, error) {
+	if mode := m.GameMode(); mode != GameModeArena {
+		return nil, fmt.Errorf("%w: %s game has no arena standings", ErrWrongGameMode, mode)
+	}
+
+	bySubteam := map[int]*ArenaStanding{}
+	for i := range m.StatsJSON {
+		s := &m.StatsJSON[i]
+
+		st, ok := bySubteam[int(s.PlayerSubteam)]
+		if !ok {
+			st = &ArenaStanding{Subteam: int(s.PlayerSubteam)}
+			bySubteam[st.Subteam] = st
+		}
+		if st.Placement == 0 {
+			st.Placement = int(s.PlayerSubteamPlacement)
+		}
+
+		p := ArenaPlayer{Participant: i}
+		for _, a := range []FlexInt64{s.PlayerAugment1, s.PlayerAugment2, s.PlayerAugment3, s.PlayerAugment4, s.PlayerAugment5, s.PlayerAugment6} {
+			if a != 0 {
+				p.Augments = append(p.Augments, AugmentID(a))
+			}
+		}
+		st.Players = append(st.Players, p)
+	}
+
+	standings := make([]ArenaStanding, 0, len(bySubteam))
+	for _, st := range bySubteam {
+		standings = append(standings, *st)
+	}
+	slices.SortFunc(standings, func(a, b ArenaStanding) int {
+		// Unknown placements go last
+		if (a.Placement == 0) != (b.Placement == 0) {
+			if a.Placement == 0 {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Or(cmp.Compare(a.Placement, b.Placement), cmp.Compare(a.Subteam, b.Subteam))
+	})
+
+	return standings, nil
+}
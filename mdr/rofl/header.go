@@ -0,0 +1,284 @@
+package rofl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Layout identifies how the sections of a ROFL file are arranged on disk.
+type Layout int
+
+const (
+	// LayoutUnknown means the header could not be decoded and the metadata was located by scanning.
+	LayoutUnknown Layout = iota
+	// LayoutLegacy is the original format: a fixed size header pointing at every section.
+	LayoutLegacy
+	// LayoutTrailer is the newer format: the metadata JSON is stored at the end of the file,
+	// followed by its length as a little endian uint32.
+	LayoutTrailer
+)
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutLegacy:
+		return "legacy"
+	case LayoutTrailer:
+		return "trailer"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	magicLength        = 6
+	signatureLength    = 256
+	legacyHeaderLength = 288
+	trailerLength      = 4
+)
+
+var magicBytes = []byte("RIOT")
+
+// Header is the binary header of a ROFL file.
+//
+// Legacy files fill every field from the fixed layout below. Trailer files only
+// carry the magic bytes and the game version at the start, so only the fields that
+// can be recovered from the file itself are set: the file length, the metadata
+// offset and length, and the header length which is also where the payload starts.
+//
+//	offset  size  field
+//	0x000   6     magic ("RIOT\x00\x00")
+//	0x006   256   signature
+//	0x106   2     header length
+//	0x108   4     file length
+//	0x10C   4     metadata offset
+//	0x110   4     metadata length
+//	0x114   4     payload header offset
+//	0x118   4     payload header length
+//	0x11C   4     payload offset
+type Header struct {
+	Layout              Layout
+	Magic               [magicLength]byte
+	Signature           []byte
+	HeaderLength        uint16
+	FileLength          uint32
+	MetadataOffset      uint32
+	MetadataLength      uint32
+	PayloadHeaderOffset uint32
+	PayloadHeaderLength uint32
+	PayloadOffset       uint32
+	// Version is the game version string trailer files store after the magic bytes,
+	// e.g. "15.23.726.9074". Legacy files keep it in the metadata instead.
+	Version string
+}
+
+// parseHeader decodes the header of the file read by r.
+// It returns a header with LayoutUnknown when neither known layout matches,
+// in which case the caller has to locate the metadata on its own.
+// Files starting like a trailer file whose trailer does not validate are
+// reported as truncated rather than unknown.
+func parseHeader(r io.ReaderAt, size int64) (Header, error) {
+	if size < magicLength {
+		return Header{}, parseError(StageHeader, 0, ErrNotRofl)
+	}
+
+	prefix, err := readAt(r, 0, min(size, legacyHeaderLength))
+	if err != nil {
+		return Header{}, parseError(StageHeader, 0, err)
+	}
+	if !bytes.HasPrefix(prefix, magicBytes) {
+		return Header{}, parseError(StageHeader, 0, ErrNotRofl)
+	}
+
+	var h Header
+	copy(h.Magic[:], prefix[:magicLength])
+
+	if ok, err := parseLegacyHeader(r, size, prefix, &h); ok || err != nil {
+		return h, err
+	}
+	if ok, err := parseTrailer(r, size, prefix, &h); ok || err != nil {
+		return h, err
+	}
+
+	// The version string is what trailer files start with, when their trailer is missing
+	// the file was cut short, typically a partial download
+	_, _, found := findVersionString(prefix)
+	if found || (int64(len(prefix)) == size && hasCutVersionString(prefix)) {
+		err := fmt.Errorf("%w: trailer file of %d bytes has no valid metadata trailer", ErrTruncated, size)
+		return Header{}, parseError(StageHeader, size, err)
+	}
+
+	h.Layout = LayoutUnknown
+	return h, nil
+}
+
+func parseLegacyHeader(r io.ReaderAt, size int64, prefix []byte, h *Header) (bool, error) {
+	if len(prefix) < legacyHeaderLength {
+		return false, nil
+	}
+
+	le := binary.LittleEndian
+	fields := prefix[magicLength+signatureLength:]
+
+	headerLength := le.Uint16(fields[0:])
+	if headerLength != legacyHeaderLength {
+		return false, nil
+	}
+
+	fileLength := le.Uint32(fields[2:])
+	metadataOffset := le.Uint32(fields[6:])
+	metadataLength := le.Uint32(fields[10:])
+	payloadHeaderOffset := le.Uint32(fields[14:])
+	payloadHeaderLength := le.Uint32(fields[18:])
+	payloadOffset := le.Uint32(fields[22:])
+
+	if int64(fileLength) > size {
+		err := fmt.Errorf("%w: header announces %d bytes, got %d", ErrTruncated, fileLength, size)
+		return false, parseError(StageHeader, size, err)
+	}
+	if int64(fileLength) != size {
+		return false, nil
+	}
+	if !inBounds(metadataOffset, metadataLength, size) || !inBounds(payloadHeaderOffset, payloadHeaderLength, size) {
+		return false, nil
+	}
+	if int64(payloadOffset) > size || metadataLength == 0 {
+		return false, nil
+	}
+	if first, err := readAt(r, int64(metadataOffset), 1); err != nil || first[0] != '{' {
+		return false, wrapHeaderError(int64(metadataOffset), err)
+	}
+
+	h.Layout = LayoutLegacy
+	h.Signature = bytes.Clone(prefix[magicLength : magicLength+signatureLength])
+	h.HeaderLength = headerLength
+	h.FileLength = fileLength
+	h.MetadataOffset = metadataOffset
+	h.MetadataLength = metadataLength
+	h.PayloadHeaderOffset = payloadHeaderOffset
+	h.PayloadHeaderLength = payloadHeaderLength
+	h.PayloadOffset = payloadOffset
+	return true, nil
+}
+
+func parseTrailer(r io.ReaderAt, size int64, prefix []byte, h *Header) (bool, error) {
+	if size < magicLength+trailerLength+2 {
+		return false, nil
+	}
+
+	trailer, err := readAt(r, size-trailerLength, trailerLength)
+	if err != nil {
+		return false, wrapHeaderError(size-trailerLength, err)
+	}
+	metadataLength := int64(binary.LittleEndian.Uint32(trailer))
+	if metadataLength < 2 || metadataLength > size-trailerLength-magicLength {
+		return false, nil
+	}
+
+	metadataOffset := size - trailerLength - metadataLength
+	first, err := readAt(r, metadataOffset, 1)
+	if err != nil {
+		return false, wrapHeaderError(metadataOffset, err)
+	}
+	last, err := readAt(r, size-trailerLength-1, 1)
+	if err != nil {
+		return false, wrapHeaderError(size-trailerLength-1, err)
+	}
+	if first[0] != '{' || last[0] != '}' {
+		return false, nil
+	}
+
+	h.Layout = LayoutTrailer
+	if start, end, ok := findVersionString(prefix[:min(int64(len(prefix)), metadataOffset)]); ok {
+		h.Version = string(prefix[start:end])
+		h.HeaderLength = uint16(end)
+		h.PayloadOffset = uint32(end)
+	}
+	h.FileLength = uint32(size)
+	h.MetadataOffset = uint32(metadataOffset)
+	h.MetadataLength = uint32(metadataLength)
+	return true, nil
+}
+
+func wrapHeaderError(offset int64, err error) error {
+	if err == nil {
+		return nil
+	}
+	return parseError(StageHeader, offset, err)
+}
+
+// versionSearchWindow bounds how far from the start of a trailer file the game version is looked for.
+const versionSearchWindow = 64
+
+// findVersionString locates the length prefixed game version string ("15.23.726.9074")
+// that trailer files store shortly after the magic bytes. It returns the bounds of the
+// string itself, without its length byte.
+func findVersionString(buf []byte) (start, end int, ok bool) {
+	window := min(len(buf), versionSearchWindow)
+
+	for i := magicLength; i < window; i++ {
+		n := int(buf[i])
+		if n < len("1.1") || i+1+n > len(buf) {
+			continue
+		}
+		if isVersionString(buf[i+1 : i+1+n]) {
+			return i + 1, i + 1 + n, true
+		}
+	}
+	return 0, 0, false
+}
+
+// hasCutVersionString reports whether buf, the whole content of a file, ends in the middle
+// of a length prefixed version string.
+func hasCutVersionString(buf []byte) bool {
+	window := min(len(buf), versionSearchWindow)
+
+	for i := magicLength; i < window; i++ {
+		n := int(buf[i])
+		rest := buf[i+1:]
+		if n < len("1.1") || len(rest) == 0 || len(rest) >= n {
+			continue
+		}
+		if rest[0] != '.' && isVersionPrefix(rest) {
+			return true
+		}
+	}
+	return false
+}
+
+// isVersionPrefix reports whether b only holds digits and single dots.
+func isVersionPrefix(b []byte) bool {
+	for i, c := range b {
+		switch {
+		case c == '.':
+			if i > 0 && b[i-1] == '.' {
+				return false
+			}
+		case c < '0' || c > '9':
+			return false
+		}
+	}
+	return true
+}
+
+func isVersionString(b []byte) bool {
+	dots := 0
+	for i, c := range b {
+		switch {
+		case c == '.':
+			if i == 0 || i == len(b)-1 || b[i-1] == '.' {
+				return false
+			}
+			dots++
+		case c < '0' || c > '9':
+			return false
+		}
+	}
+	return dots >= 1
+}
+
+// inBounds reports whether the section [offset, offset+length) fits in a file of the given size.
+func inBounds(offset, length uint32, size int64) bool {
+	return int64(offset)+int64(length) <= size
+}
@@ -0,0 +1,192 @@
+package rofl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testMetadata = `{"gameLength":1500000,"lastGameChunkId":1,"lastKeyFrameId":1,"statsJson":"[]"}`
+
+// legacyFile builds a legacy replay: the header, then the metadata, the payload header and the payload.
+func legacyFile(metadata, payloadHeader, payload []byte) []byte {
+	metadataOffset := legacyHeaderLength
+	payloadHeaderOffset := metadataOffset + len(metadata)
+	payloadOffset := payloadHeaderOffset + len(payloadHeader)
+	size := payloadOffset + len(payload)
+
+	le := binary.LittleEndian
+	buf := make([]byte, legacyHeaderLength, size)
+	copy(buf, "RIOT\x00\x00")
+	fields := buf[magicLength+signatureLength:]
+	le.PutUint16(fields[0:], legacyHeaderLength)
+	le.PutUint32(fields[2:], uint32(size))
+	le.PutUint32(fields[6:], uint32(metadataOffset))
+	le.PutUint32(fields[10:], uint32(len(metadata)))
+	le.PutUint32(fields[14:], uint32(payloadHeaderOffset))
+	le.PutUint32(fields[18:], uint32(len(payloadHeader)))
+	le.PutUint32(fields[22:], uint32(payloadOffset))
+
+	buf = append(buf, metadata...)
+	buf = append(buf, payloadHeader...)
+	return append(buf, payload...)
+}
+
+// trailerFile builds a trailer replay: the magic bytes and the game version, then the
+// payload, the metadata and its length.
+func trailerFile(version string, payload, metadata []byte) []byte {
+	buf := []byte("RIOT\x02\x00\x00\x00\x00\x00\x00\x00")
+	buf = append(buf, byte(len(version)))
+	buf = append(buf, version...)
+	buf = append(buf, payload...)
+	buf = append(buf, metadata...)
+	return binary.LittleEndian.AppendUint32(buf, uint32(len(metadata)))
+}
+
+func TestParseHeader(t *testing.T) {
+	legacy := legacyFile([]byte(testMetadata), make([]byte, payloadHeaderFixedLength), []byte("payload"))
+	trailer := trailerFile("15.23.726.9074", []byte("payload"), []byte(testMetadata))
+
+	// A legacy header whose metadata offset points past the end of the file
+	outOfBounds := bytes.Clone(legacy)
+	binary.LittleEndian.PutUint32(outOfBounds[magicLength+signatureLength+6:], uint32(len(legacy)))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Header
+		wantErr error
+	}{
+		{
+			name: "legacy",
+			data: legacy,
+			want: Header{
+				Layout:              LayoutLegacy,
+				HeaderLength:        legacyHeaderLength,
+				FileLength:          uint32(len(legacy)),
+				MetadataOffset:      legacyHeaderLength,
+				MetadataLength:      uint32(len(testMetadata)),
+				PayloadHeaderOffset: legacyHeaderLength + uint32(len(testMetadata)),
+				PayloadHeaderLength: payloadHeaderFixedLength,
+				PayloadOffset:       legacyHeaderLength + uint32(len(testMetadata)) + payloadHeaderFixedLength,
+			},
+		},
+		{
+			name: "trailer",
+			data: trailer,
+			want: Header{
+				Layout:         LayoutTrailer,
+				HeaderLength:   27,
+				FileLength:     uint32(len(trailer)),
+				MetadataOffset: 27 + uint32(len("payload")),
+				MetadataLength: uint32(len(testMetadata)),
+				PayloadOffset:  27,
+				Version:        "15.23.726.9074",
+			},
+		},
+		{
+			name: "unknown layout",
+			data: []byte("RIOTxx\x00\x00\x00\x00" + testMetadata + "garbage"),
+			want: Header{Layout: LayoutUnknown},
+		},
+		{
+			name: "legacy metadata out of bounds",
+			data: outOfBounds,
+			want: Header{Layout: LayoutUnknown},
+		},
+		{name: "empty", data: nil, wantErr: ErrNotRofl},
+		{name: "shorter than the magic", data: []byte("RIOT"), wantErr: ErrNotRofl},
+		{name: "wrong magic", data: []byte("RIFF\x00\x00" + testMetadata), wantErr: ErrNotRofl},
+		{name: "legacy truncated", data: legacy[:len(legacy)-3], wantErr: ErrTruncated},
+		{name: "legacy cut in the header", data: legacy[:legacyHeaderLength-1], want: Header{Layout: LayoutUnknown}},
+		{name: "trailer truncated", data: trailer[:len(trailer)-10], wantErr: ErrTruncated},
+		{name: "trailer cut after the version", data: trailer[:30], wantErr: ErrTruncated},
+		{name: "trailer cut in the version", data: trailer[:15], wantErr: ErrTruncated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := parseHeader(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parseHeader() error = %v, want %v", err, tt.wantErr)
+				}
+				var perr *ParseError
+				if !errors.As(err, &perr) || perr.Stage != StageHeader {
+					t.Errorf("parseHeader() error = %#v, want a *ParseError of stage %q", err, StageHeader)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHeader() error = %v", err)
+			}
+
+			h.Magic, h.Signature = [magicLength]byte{}, nil
+			if !reflect.DeepEqual(h, tt.want) {
+				t.Errorf("parseHeader() = %+v, want %+v", h, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRoflErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr []error
+	}{
+		{
+			name:    "no metadata",
+			data:    []byte("RIOTxx\x00\x00\x00\x00 no metadata here"),
+			wantErr: []error{ErrUnsupportedVersion, ErrMetadataNotFound},
+		},
+		{
+			name:    "unknown layout with unclosed metadata",
+			data:    []byte("RIOTxx\x00\x00\x00\x00" + testMetadata[:20]),
+			wantErr: []error{ErrTruncated},
+		},
+		{
+			name:    "trailer with invalid metadata",
+			data:    trailerFile("15.23.726.9074", nil, []byte(`{"gameLength":}`)),
+			wantErr: []error{ErrCorrupted},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRofl(tt.data)
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("ParseRofl() error = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindVersionString(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  string
+		want string
+	}{
+		{name: "trailer prefix", buf: "RIOT\x02\x00\x00\x00\x00\x00\x00\x00\x0e15.23.726.9074", want: "15.23.726.9074"},
+		{name: "short version", buf: "RIOT\x02\x00\x0515.23", want: "15.23"},
+		{name: "no dot", buf: "RIOT\x02\x00\x0512345"},
+		{name: "double dot", buf: "RIOT\x02\x00\x0515..3"},
+		{name: "length past the end", buf: "RIOT\x02\x00\x0e15.23"},
+		{name: "too short for a magic", buf: "RIO"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := findVersionString([]byte(tt.buf))
+			got := ""
+			if ok {
+				got = tt.buf[start:end]
+			}
+			if got != tt.want {
+				t.Errorf("findVersionString(%q) = %q, want %q", tt.buf, got, tt.want)
+			}
+		})
+	}
+}
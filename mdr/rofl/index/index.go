@@ -0,0 +1,454 @@
+// Package index keeps the metadata of a collection of replays in a SQLite database,
+// so that questions across thousands of replays are SQL queries instead of a parse
+// of every file.
+//
+// The database holds three tables:
+//
+//	games              one row per replay file, with its path, size and modification time
+//	participants       the main fields of each participant (PUUID, champion, position, ...)
+//	participant_stats  every stat of each participant as a key/value row
+//
+// participant_stats keeps the keys as written in the metadata (e.g. "GOLD_EARNED"),
+// including the ones StatsJSON has no field for, so that stats added by new patches are
+// indexed without a schema change. Values are stored as integers, reals or text, see
+// StatsJSON.StatValue.
+//
+// Indexing a directory again only parses the replays that are new or whose size or
+// modification time changed, and drops the replays that were removed from it.
+package index
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+// schemaVersion is stored in the user_version pragma of the database.
+const schemaVersion = 1
+
+const schema = `
+CREATE TABLE IF NOT EXISTS games (
+	id            INTEGER PRIMARY KEY,
+	path          TEXT NOT NULL UNIQUE,
+	size          INTEGER NOT NULL,
+	mod_time      INTEGER NOT NULL, -- Unix nanoseconds
+	game_id       INTEGER,
+	platform      TEXT,
+	match_id      TEXT,
+	game_version  TEXT,
+	patch         TEXT,
+	game_length   INTEGER NOT NULL, -- milliseconds
+	game_mode     TEXT NOT NULL,
+	winning_team  INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS participants (
+	game              INTEGER NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+	participant       INTEGER NOT NULL, -- from 1, in the order of the metadata
+	puuid             TEXT,
+	riot_id_game_name TEXT,
+	riot_id_tag_line  TEXT,
+	champion          TEXT,             -- the SKIN stat, e.g. MonkeyKing
+	team              INTEGER,
+	position          TEXT,             -- TEAM_POSITION, NULL when the game has none
+	won               INTEGER NOT NULL,
+	kills             INTEGER NOT NULL,
+	deaths            INTEGER NOT NULL,
+	assists           INTEGER NOT NULL,
+	PRIMARY KEY (game, participant)
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS participant_stats (
+	game        INTEGER NOT NULL,
+	participant INTEGER NOT NULL,
+	key         TEXT NOT NULL,
+	value,      -- no declared type: integers, reals and text are kept as given
+	PRIMARY KEY (game, participant, key),
+	FOREIGN KEY (game, participant) REFERENCES participants (game, participant) ON DELETE CASCADE
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS games_patch ON games (patch);
+CREATE INDEX IF NOT EXISTS participants_puuid ON participants (puuid);
+CREATE INDEX IF NOT EXISTS participants_champion ON participants (champion);
+CREATE INDEX IF NOT EXISTS participants_position ON participants (position);
+`
+
+// Option configures a DB.
+type Option func(*options)
+
+type options struct {
+	workers   int
+	parseOpts []rofl.Option
+}
+
+// WithWorkers sets the number of replays parsed concurrently, the number of CPUs by default.
+// Writes to the database are serialized whatever the number of workers.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// WithParseOptions sets the options replays are parsed with.
+func WithParseOptions(opts ...rofl.Option) Option {
+	return func(o *options) {
+		o.parseOpts = opts
+	}
+}
+
+// DB is a replay index.
+type DB struct {
+	db   *sql.DB
+	opts options
+}
+
+// Open opens the index stored at path, creating it if needed.
+func Open(path string, opts ...Option) (*DB, error) {
+	o := options{workers: runtime.NumCPU()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer, one connection avoids waiting on our own locks
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("index %s: %w", path, err)
+	}
+	return &DB{db: db, opts: o}, nil
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	switch version {
+	case schemaVersion:
+		return nil
+	case 0:
+		if _, err := db.Exec(schema); err != nil {
+			return err
+		}
+		_, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion))
+		return err
+	default:
+		return fmt.Errorf("unsupported schema version %d, want %d", version, schemaVersion)
+	}
+}
+
+// SQL returns the database, for queries.
+func (d *DB) SQL() *sql.DB {
+	return d.db
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// FileError reports a replay that could not be indexed.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Result sums up an Index run.
+type Result struct {
+	// Indexed counts the replays parsed and written, Unchanged the ones skipped.
+	Indexed   int
+	Unchanged int
+	// Removed counts the replays dropped from the index as their file is gone.
+	Removed int
+	// Errors holds a *FileError for every replay that could not be indexed.
+	// They are retried on the next run.
+	Errors []error
+}
+
+type file struct {
+	path    string
+	size    int64
+	modTime int64
+}
+
+type parsed struct {
+	file
+	replay *rofl.RoflFile
+	err    error
+}
+
+// Index brings the index up to date with the .rofl files under dirs. Replay errors are
+// reported in Result.Errors; the returned error is for the walk and the database.
+func (d *DB) Index(ctx context.Context, dirs ...string) (Result, error) {
+	var res Result
+	// Stops the parsing workers when a database error ends the run early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	roots := make([]string, len(dirs))
+	var files []file
+	seen := map[string]bool{}
+	for i, dir := range dirs {
+		root, err := filepath.Abs(dir)
+		if err != nil {
+			return res, err
+		}
+		roots[i] = root
+		err = filepath.WalkDir(root, func(path string, e fs.DirEntry, err error) error {
+			if err != nil || e.IsDir() || !strings.EqualFold(filepath.Ext(path), ".rofl") || seen[path] {
+				return err
+			}
+			info, err := e.Info()
+			if err != nil {
+				return err
+			}
+			seen[path] = true
+			files = append(files, file{path: path, size: info.Size(), modTime: info.ModTime().UnixNano()})
+			return nil
+		})
+		if err != nil {
+			return res, err
+		}
+	}
+
+	indexed, err := d.indexed(ctx)
+	if err != nil {
+		return res, err
+	}
+	var todo []file
+	for _, f := range files {
+		if old, ok := indexed[f.path]; ok && old == f {
+			res.Unchanged++
+			continue
+		}
+		todo = append(todo, f)
+	}
+
+	for r := range d.parse(ctx, todo) {
+		if r.err != nil {
+			res.Errors = append(res.Errors, &FileError{Path: r.path, Err: r.err})
+			// The replay changed and no longer parses, what is indexed of it is stale
+			if _, err := d.db.ExecContext(ctx, "DELETE FROM games WHERE path = ?", r.path); err != nil {
+				return res, err
+			}
+			continue
+		}
+		if err := d.store(ctx, r.file, r.replay); err != nil {
+			return res, err
+		}
+		res.Indexed++
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+
+	for path := range indexed {
+		if seen[path] || !under(path, roots) {
+			continue
+		}
+		if _, err := d.db.ExecContext(ctx, "DELETE FROM games WHERE path = ?", path); err != nil {
+			return res, err
+		}
+		res.Removed++
+	}
+	return res, nil
+}
+
+// indexed returns the files of the index, by path.
+func (d *DB) indexed(ctx context.Context) (map[string]file, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT path, size, mod_time FROM games")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := map[string]file{}
+	for rows.Next() {
+		var f file
+		if err := rows.Scan(&f.path, &f.size, &f.modTime); err != nil {
+			return nil, err
+		}
+		files[f.path] = f
+	}
+	return files, rows.Err()
+}
+
+// parse parses the files with the configured number of workers. The channel is closed
+// once every file is parsed or the context is done.
+func (d *DB) parse(ctx context.Context, files []file) <-chan parsed {
+	jobs := make(chan file)
+	results := make(chan parsed)
+
+	var wg sync.WaitGroup
+	for range min(d.opts.workers, max(len(files), 1)) {
+		wg.Go(func() {
+			for f := range jobs {
+				replay, err := d.open(f.path)
+				select {
+				case results <- parsed{file: f, replay: replay, err: err}:
+				case <-ctx.Done():
+				}
+			}
+		})
+	}
+	go func() {
+		defer close(jobs)
+		for _, f := range files {
+			select {
+			case jobs <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// open parses the header and metadata of the replay at path, the rest of the file is never read.
+func (d *DB) open(path string) (*rofl.RoflFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return rofl.NewReader(f, info.Size(), append(slices.Clone(d.opts.parseOpts), rofl.WithName(path))...)
+}
+
+// store replaces the rows of the file with the content of the replay.
+func (d *DB) store(ctx context.Context, f file, replay *rofl.RoflFile) (err error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	// Deleting cascades to the participants and their stats
+	if _, err := tx.ExecContext(ctx, "DELETE FROM games WHERE path = ?", f.path); err != nil {
+		return err
+	}
+
+	var gameID, version, patch, winner any
+	if id := replay.PayloadHeader.GameID; id != 0 {
+		gameID = int64(id)
+	}
+	if v := replay.GameVersion; !v.IsZero() {
+		version, patch = v.String(), v.Patch()
+	}
+	if team, err := replay.Metadata.Winner(); err == nil && team != rofl.TeamUnknown {
+		winner = int(team)
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO games
+		(path, size, mod_time, game_id, platform, match_id, game_version, patch, game_length, game_mode, winning_team)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		f.path, f.size, f.modTime, gameID,
+		nullString(replay.PayloadHeader.Platform), nullString(replay.PayloadHeader.MatchID()),
+		version, patch, int64(replay.Metadata.GameLength), replay.Metadata.GameMode().String(), winner)
+	if err != nil {
+		return err
+	}
+	game, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	insertParticipant, err := tx.PrepareContext(ctx, `INSERT INTO participants
+		(game, participant, puuid, riot_id_game_name, riot_id_tag_line, champion, team, position, won, kills, deaths, assists)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insertParticipant.Close()
+	insertStat, err := tx.PrepareContext(ctx, "INSERT INTO participant_stats (game, participant, key, value) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer insertStat.Close()
+
+	// Conversion errors leave the fields they name empty, every stat is still stored as is
+	participants, _ := replay.Metadata.Participants()
+	keys := rofl.StatKeys()
+	for i, p := range participants {
+		var team any
+		if p.Team != rofl.TeamUnknown {
+			team = int(p.Team)
+		}
+		_, err := insertParticipant.ExecContext(ctx, game, i+1,
+			nullString(p.PUUID), nullString(p.RiotIDGameName), nullString(p.RiotIDTagLine),
+			nullString(p.Champion), team, nullString(p.TeamPosition.String()),
+			p.Won, p.Kills, p.Deaths, p.Assists)
+		if err != nil {
+			return err
+		}
+
+		s := &replay.Metadata.StatsJSON[i]
+		for _, key := range slices.Concat(keys, s.UnknownKeys()) {
+			v, ok := s.StatValue(key)
+			if !ok {
+				continue
+			}
+			if _, err := insertStat.ExecContext(ctx, game, i+1, key, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// under reports whether path is inside one of the roots.
+func under(path string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
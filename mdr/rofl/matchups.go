@@ -0,0 +1,129 @@
+package rofl
+
+// MatchMethod tells how a participant was paired with its opponent.
+type MatchMethod int
+
+const (
+	// MatchNone means the participant has no opponent, e.g. in Arena.
+	MatchNone MatchMethod = iota
+	// MatchPosition pairs the participants of both teams playing the same position.
+	MatchPosition
+	// MatchOrder pairs the participants left without a position opponent in the order
+	// of the metadata, which is the order of the loading screen. It is used when
+	// positions are empty, as in ARAM, or ambiguous.
+	MatchOrder
+)
+
+func (m MatchMethod) String() string {
+	switch m {
+	case MatchPosition:
+		return "position"
+	case MatchOrder:
+		return "order"
+	default:
+		return "none"
+	}
+}
+
+// MatchupDiff is the difference between a participant and its opponent, positive
+// when the participant is ahead. CS is MINIONS_KILLED + NEUTRAL_MINIONS_KILLED and
+// Damage the damage dealt to champions.
+type MatchupDiff struct {
+	Gold        int64
+	CS          int64
+	Damage      int64
+	VisionScore int64
+	Level       int64
+}
+
+// Matchup pairs a participant with its lane opponent.
+type Matchup struct {
+	// Participant and Opponent index Metadata.StatsJSON. Opponent is -1 when there is none.
+	Participant int
+	Opponent    int
+	Position    Position
+	Method      MatchMethod
+	Diff        MatchupDiff
+}
+
+// Matchups returns the matchup of every participant, in the order of m.StatsJSON.
+//
+// Participants are paired by TEAM_POSITION, or INDIVIDUAL_POSITION when it is empty, when each
+// team has exactly one participant at that position. The others are paired in order, see
+// MatchOrder. Arena games have no lanes: every participant is returned without opponent,
+// see ArenaStandings instead.
+func (m *Metadata) Matchups() []Matchup {
+	matchups := make([]Matchup, len(m.StatsJSON))
+	for i := range matchups {
+		matchups[i] = Matchup{Participant: i, Opponent: -1}
+	}
+
+	if m.GameMode() == GameModeArena {
+		return matchups
+	}
+
+	type slot struct {
+		position Position
+		team     Team
+	}
+	bySlot := map[slot][]int{}
+	for i := range m.StatsJSON {
+		s := &m.StatsJSON[i]
+		p, err := ParsePosition(s.TeamPosition)
+		if err != nil || p == PositionNone {
+			p, _ = ParsePosition(s.IndividualPosition)
+		}
+		matchups[i].Position = p
+		if p != PositionNone {
+			k := slot{p, Team(s.Team)}
+			bySlot[k] = append(bySlot[k], i)
+		}
+	}
+
+	for k, blue := range bySlot {
+		if k.team != TeamBlue {
+			continue
+		}
+		red := bySlot[slot{k.position, TeamRed}]
+		if len(blue) == 1 && len(red) == 1 {
+			m.pair(matchups, blue[0], red[0], MatchPosition)
+		}
+	}
+
+	var blue, red []int
+	for i := range matchups {
+		if matchups[i].Method != MatchNone {
+			continue
+		}
+		switch Team(m.StatsJSON[i].Team) {
+		case TeamBlue:
+			blue = append(blue, i)
+		case TeamRed:
+			red = append(red, i)
+		}
+	}
+	for j := range min(len(blue), len(red)) {
+		m.pair(matchups, blue[j], red[j], MatchOrder)
+	}
+
+	return matchups
+}
+
+func (m *Metadata) pair(matchups []Matchup, a, b int, method MatchMethod) {
+	for _, ab := range [][2]int{{a, b}, {b, a}} {
+		mt := &matchups[ab[0]]
+		mt.Opponent = ab[1]
+		mt.Method = method
+		mt.Diff = diff(&m.StatsJSON[ab[0]], &m.StatsJSON[ab[1]])
+	}
+}
+
+func diff(s, o *StatsJSON) MatchupDiff {
+	return MatchupDiff{
+		Gold:        int64(s.GoldEarned - o.GoldEarned),
+		CS:          int64(s.MinionsKilled + s.NeutralMinionsKilled - o.MinionsKilled - o.NeutralMinionsKilled),
+		Damage:      int64(s.TotalDamageDealtToChampions - o.TotalDamageDealtToChampions),
+		VisionScore: int64(s.VisionScore - o.VisionScore),
+		Level:       int64(s.Level - o.Level),
+	}
+}
@@ -3,82 +3,286 @@ package rofl
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"io/fs"
+	"log/slog"
 	"os"
+	"slices"
+	"sync"
 )
 
+// RoflFile is a parsed replay.
+//
+// Only the header, the payload header and the metadata are read when it is created,
+// chunks and keyframes are read from the underlying reader when they are requested.
+// FileBuffer and BytesWithoutMetadata are only filled when the whole file is loaded in memory,
+// that is by every constructor but NewReader.
+// Chunks and keyframes may be read and decoded from several goroutines at once.
 type RoflFile struct {
-	FileBuffer           []byte
+	FileBuffer []byte
+	// Path is the name the replay was opened with, empty when it was parsed from bytes
+	// or a reader without WithName.
+	// Replay file names carry the platform (e.g. EUW1-7610660427.rofl), see PayloadHeader.
 	Path                 string
+	Header               Header
+	PayloadHeader        PayloadHeader
 	MetadataOffset       uint64
 	Metadata             Metadata
 	MetadataString       string
 	BytesWithoutMetadata []byte
+	// GameVersion is the client version the replay was recorded with,
+	// zero when the file does not say.
+	GameVersion GameVersion
+
+	r        io.ReaderAt
+	size     int64
+	logger   *slog.Logger
+	segments func() (*SegmentIndex, error)
 }
 
-func OpenRoflFile(path string) (*RoflFile, error) {
-	file, err := os.Open(path)
+// OpenRoflFile reads and parses the replay at path.
+func OpenRoflFile(path string, opts ...Option) (*RoflFile, error) {
+	buf, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	return parseBuffer(buf, path, opts)
+}
 
-	// Ensure file is closed on failure
-	defer file.Close()
-
-	if _, err = file.Seek(0, io.SeekStart); err != nil {
+// OpenRoflFS reads and parses the replay called name in fsys.
+func OpenRoflFS(fsys fs.FS, name string, opts ...Option) (*RoflFile, error) {
+	buf, err := fs.ReadFile(fsys, name)
+	if err != nil {
 		return nil, err
 	}
+	return parseBuffer(buf, name, opts)
+}
+
+// ParseRofl parses a replay held in memory. The returned file keeps a reference to data.
+// Path is left empty unless set with WithName, so the platform can not be derived from the file name.
+func ParseRofl(data []byte, opts ...Option) (*RoflFile, error) {
+	return parseBuffer(data, "", opts)
+}
 
-	buf, err := io.ReadAll(file)
+// ReadRofl reads a whole replay from r and parses it.
+// Path is left empty unless set with WithName, so the platform can not be derived from the file name.
+func ReadRofl(r io.Reader, opts ...Option) (*RoflFile, error) {
+	buf, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
+	return parseBuffer(buf, "", opts)
+}
 
-	// Check for "RIOT" magic bytes at the start of the file
-	if !bytes.HasPrefix(buf, []byte("RIOT")) {
-		return nil, fmt.Errorf("file is not a valid ROFL file")
+func parseBuffer(buf []byte, path string, opts []Option) (*RoflFile, error) {
+	r, err := newRoflFile(bytes.NewReader(buf), int64(len(buf)), path, opts)
+	if err != nil {
+		return nil, err
 	}
-	metadataOffset := uint64(0)
 
-	if pos := bytes.Index(buf, []byte(`{"gameLength"`)); pos >= 0 {
-		metadataOffset = uint64(pos)
-		log.Printf("Metadata offset found at: %d", metadataOffset)
-	} else {
-		return nil, fmt.Errorf("metadata offset not found")
+	r.FileBuffer = buf
+	// Calculate bytes without metadata
+	r.BytesWithoutMetadata = buf[:r.MetadataOffset]
+
+	return r, nil
+}
+
+// NewReader parses a replay from r without loading it in memory: only the header and
+// metadata sections are read. The reader must stay valid as long as chunks or keyframes
+// are read from the returned file. Path is left empty unless set with WithName.
+func NewReader(r io.ReaderAt, size int64, opts ...Option) (*RoflFile, error) {
+	return newRoflFile(r, size, "", opts)
+}
+
+func newRoflFile(r io.ReaderAt, size int64, path string, opts []Option) (*RoflFile, error) {
+	o := newOptions(opts)
+	if path == "" {
+		path = o.name
 	}
+	logger := o.logger.With(slog.String("path", path))
+	logger.Debug("parsing replay", slog.Int64("size", size))
 
-	metadataBytes := buf[metadataOffset:]
+	header, err := parseHeader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("header parsed",
+		slog.String("layout", header.Layout.String()),
+		slog.Uint64("header_length", uint64(header.HeaderLength)),
+		slog.Uint64("payload_header_offset", uint64(header.PayloadHeaderOffset)),
+		slog.Uint64("payload_header_length", uint64(header.PayloadHeaderLength)),
+		slog.Uint64("payload_offset", uint64(header.PayloadOffset)),
+	)
 
-	jsonBytes, err := extractJSON(metadataBytes)
+	metadataOffset, jsonBytes, err := locateMetadata(r, size, &header, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to locate metadata JSON: %w", err)
+		return nil, err
 	}
 
 	metadata, err := UnmarshalMetadata(jsonBytes)
 	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling metadata: %w", err)
+		return nil, parseError(StageMetadata, int64(metadataOffset), fmt.Errorf("%w: error unmarshaling metadata: %w", ErrCorrupted, err))
+	}
+	logger.Debug("metadata decoded",
+		slog.Int64("game_length_ms", int64(metadata.GameLength)),
+		slog.Int64("last_chunk_id", int64(metadata.LastGameChunkID)),
+		slog.Int64("last_keyframe_id", int64(metadata.LastKeyFrameID)),
+		slog.Int("participants", len(metadata.StatsJSON)),
+	)
+
+	version := detectGameVersion(header, metadata)
+	decoder, ok := DecoderFor(version)
+	if ok {
+		logger.Debug("version decoder selected",
+			slog.String("version", version.String()),
+			slog.String("since", decoder.Since.String()),
+		)
+	} else {
+		logger.Debug("no version decoder, using defaults", slog.String("version", version.String()))
+	}
+	if decoder.FixMetadata != nil {
+		before := slices.Clone(metadata.StatsJSON)
+		decoder.FixMetadata(&metadata)
+		for i := range min(len(before), len(metadata.StatsJSON)) {
+			metadata.StatsJSON[i].syncRaw(&before[i])
+		}
 	}
 
 	b, err := json.MarshalIndent(metadata, "", "  ")
 	if err != nil {
-		return nil, fmt.Errorf("error marshaling metadata: %w", err)
+		return nil, parseError(StageMetadata, int64(metadataOffset), fmt.Errorf("error marshaling metadata: %w", err))
 	}
 
-	// Calculate bytes without metadata
-	bytesWithoutMetadata := buf[:metadataOffset]
+	payloadHeader, err := readPayloadHeader(r, header, metadata, path)
+	if err != nil {
+		return nil, parseError(StagePayloadHeader, int64(header.PayloadHeaderOffset), err)
+	}
+	logger.Debug("payload header decoded",
+		slog.Uint64("game_id", payloadHeader.GameID),
+		slog.String("platform", payloadHeader.Platform),
+		slog.Uint64("chunk_count", uint64(payloadHeader.ChunkCount)),
+		slog.Uint64("keyframe_count", uint64(payloadHeader.KeyframeCount)),
+		slog.Bool("encrypted", payloadHeader.EncryptionKey != ""),
+	)
 
-	r := &RoflFile{
-		FileBuffer:           buf,
-		Path:                 path,
-		MetadataOffset:       metadataOffset,
-		Metadata:             metadata,
-		MetadataString:       string(b),
-		BytesWithoutMetadata: bytesWithoutMetadata,
+	f := &RoflFile{
+		Path:           path,
+		Header:         header,
+		PayloadHeader:  payloadHeader,
+		MetadataOffset: metadataOffset,
+		Metadata:       metadata,
+		MetadataString: string(b),
+		GameVersion:    version,
+		r:              r,
+		size:           size,
+		logger:         logger,
 	}
+	f.segments = sync.OnceValues(f.indexSegments)
+	return f, nil
+}
 
-	return r, nil
+// ReadMetadataJSON returns the metadata JSON of the replay read by r without decoding it,
+// e.g. to look at stats StatsJSON has no field for. It is located the same way as when
+// parsing the replay, from the header or by scanning files of an unknown layout.
+func ReadMetadataJSON(r io.ReaderAt, size int64) ([]byte, error) {
+	header, err := parseHeader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	_, jsonBytes, err := locateMetadata(r, size, &header, slog.New(slog.DiscardHandler))
+	return jsonBytes, err
+}
+
+// locateMetadata returns the offset and bytes of the metadata JSON. The bounds of the
+// metadata are filled in header when they had to be found by scanning.
+func locateMetadata(r io.ReaderAt, size int64, header *Header, logger *slog.Logger) (uint64, []byte, error) {
+	if header.Layout != LayoutUnknown {
+		metadataOffset := uint64(header.MetadataOffset)
+		metadataBytes, err := readAt(r, int64(metadataOffset), int64(header.MetadataLength))
+		if err != nil {
+			return 0, nil, parseError(StageMetadata, int64(metadataOffset), err)
+		}
+		logger.Debug("metadata located from header",
+			slog.Uint64("offset", metadataOffset),
+			slog.Uint64("length", uint64(header.MetadataLength)),
+		)
+
+		jsonBytes, err := extractJSON(metadataBytes)
+		if err != nil {
+			return 0, nil, parseError(StageMetadata, int64(metadataOffset), fmt.Errorf("%w: %w", ErrCorrupted, err))
+		}
+		return metadataOffset, jsonBytes, nil
+	}
+
+	// Unknown layout, fall back to scanning for the start of the metadata JSON
+	logger.Debug("unknown layout, scanning for metadata")
+	buf, err := readAt(r, 0, size)
+	if err != nil {
+		return 0, nil, parseError(StageMetadata, 0, err)
+	}
+
+	pos := bytes.Index(buf, []byte(`{"gameLength"`))
+	if pos < 0 {
+		return 0, nil, parseError(StageMetadata, -1, fmt.Errorf("%w: %w", ErrUnsupportedVersion, ErrMetadataNotFound))
+	}
+	metadataOffset := uint64(pos)
+	header.MetadataOffset = uint32(pos)
+
+	jsonBytes, err := extractJSON(buf[metadataOffset:])
+	if err != nil {
+		// Nothing bounds the metadata in an unknown layout, if it does not close the file was cut short
+		return 0, nil, parseError(StageMetadata, int64(metadataOffset), fmt.Errorf("%w: %w", ErrTruncated, err))
+	}
+	header.MetadataLength = uint32(len(jsonBytes))
+	logger.Debug("metadata located by scanning",
+		slog.Uint64("offset", metadataOffset),
+		slog.Uint64("length", uint64(header.MetadataLength)),
+	)
+	return metadataOffset, jsonBytes, nil
+}
+
+// readPayloadHeader decodes the payload header section of legacy files and rebuilds
+// what it can from the file name and the metadata for the other layouts.
+func readPayloadHeader(r io.ReaderAt, header Header, metadata Metadata, path string) (PayloadHeader, error) {
+	var p PayloadHeader
+
+	if header.Layout == LayoutLegacy {
+		data, err := readAt(r, int64(header.PayloadHeaderOffset), int64(header.PayloadHeaderLength))
+		if err != nil {
+			return PayloadHeader{}, err
+		}
+		if p, err = parsePayloadHeader(data); err != nil {
+			return PayloadHeader{}, err
+		}
+	} else {
+		p.GameLength = uint32(metadata.GameLength)
+		p.ChunkCount = uint32(metadata.LastGameChunkID)
+		p.KeyframeCount = uint32(metadata.LastKeyFrameID)
+	}
+
+	if platform, gameID, ok := parseReplayName(path); ok {
+		p.Platform = platform
+		if p.GameID == 0 {
+			p.GameID = gameID
+		}
+	}
+
+	return p, nil
+}
+
+// readAt reads exactly n bytes at off. Reading past the end of r is reported as ErrTruncated.
+func readAt(r io.ReaderAt, off, n int64) ([]byte, error) {
+	buf := make([]byte, n)
+	read, err := r.ReadAt(buf, off)
+	if int64(read) == n {
+		return buf, nil
+	}
+	if err == nil || err == io.EOF {
+		return nil, fmt.Errorf("%w: wanted %d bytes at offset %d, got %d", ErrTruncated, n, off, read)
+	}
+	return nil, err
 }
 
 func extractJSON(data []byte) ([]byte, error) {
@@ -121,5 +325,5 @@ func extractJSON(data []byte) ([]byte, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("metadata JSON did not close")
+	return nil, errors.New("metadata JSON did not close")
 }
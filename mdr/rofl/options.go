@@ -0,0 +1,127 @@
+package rofl
+
+import (
+	"context"
+	"log/slog"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Option configures how a replay is parsed.
+type Option func(*options)
+
+type options struct {
+	logger *slog.Logger
+	name   string
+}
+
+func newOptions(opts []Option) options {
+	o := options{logger: slog.New(slog.DiscardHandler)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithLogger sets the logger receiving the debug output of every parsing stage:
+// detected layout, section offsets and sizes, fallbacks and segment decoding.
+// Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+// WithName sets the file name of a replay parsed from bytes or a reader. It becomes
+// the Path of the file and, as for OpenRoflFile, the platform and game ID of trailer
+// files are derived from it (PLATFORM-GAMEID.rofl).
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithZapLogger is WithLogger for callers using zap.
+func WithZapLogger(logger *zap.Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = slog.New(&zapHandler{core: logger.Core()})
+		}
+	}
+}
+
+// zapHandler is a slog.Handler writing to a zap core.
+type zapHandler struct {
+	core   zapcore.Core
+	group  string
+	fields []zapcore.Field
+}
+
+func (h *zapHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *zapHandler) Handle(_ context.Context, r slog.Record) error {
+	entry := zapcore.Entry{
+		Level:   zapLevel(r.Level),
+		Time:    r.Time,
+		Message: r.Message,
+	}
+
+	ce := h.core.Check(entry, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := make([]zapcore.Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = append(fields, h.field(a))
+		return true
+	})
+
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *zapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.fields = append([]zapcore.Field(nil), h.fields...)
+	for _, a := range attrs {
+		next.fields = append(next.fields, h.field(a))
+	}
+	return &next
+}
+
+func (h *zapHandler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.group = h.qualify(name)
+	return &next
+}
+
+func (h *zapHandler) field(a slog.Attr) zapcore.Field {
+	return zap.Any(h.qualify(a.Key), a.Value.Resolve().Any())
+}
+
+func (h *zapHandler) qualify(key string) string {
+	if h.group == "" {
+		return key
+	}
+	return h.group + "." + key
+}
+
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
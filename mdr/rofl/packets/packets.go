@@ -0,0 +1,213 @@
+// Package packets iterates over the game packets of a decoded replay chunk.
+//
+// A decoded chunk, as returned by rofl.DecodeSegment, is a flat sequence of blocks.
+// Each block starts with a marker byte whose high bits tell which of the following
+// fields are stored in full and which are compressed against the previous block:
+//
+//	bit   set                                   unset
+//	0x80  time: uint8 delta in milliseconds     time: float32 in seconds
+//	0x40  packet ID: same as previous block     packet ID: uint16
+//	0x20  net ID: uint8 delta from previous     net ID: uint32
+//	0x10  length: uint8                         length: uint32
+//
+// The low nibble of the marker is the channel the packet was sent on.
+// Fields follow the marker in this order: time, length, packet ID, net ID, payload.
+package packets
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"time"
+)
+
+const (
+	flagRelativeTime  = 0x80
+	flagSameID        = 0x40
+	flagRelativeNetID = 0x20
+	flagShortLength   = 0x10
+	channelMask       = 0x0F
+)
+
+// Packet is one game packet of a chunk.
+// Payload aliases the chunk data and must be copied to be kept after the chunk is released.
+type Packet struct {
+	Time    time.Duration
+	Channel uint8
+	ID      uint16
+	Name    string
+	NetID   uint32
+	Payload []byte
+}
+
+// Table names the packet IDs of a given patch. Packet IDs are reshuffled by Riot from
+// one patch to the next, so a table only makes sense for the patch it was built for.
+type Table map[uint16]string
+
+// Name returns the name of a packet ID, ok is false for IDs the table does not know.
+func (t Table) Name(id uint16) (name string, ok bool) {
+	name, ok = t[id]
+	return name, ok
+}
+
+// ID returns the packet ID the table gives to name.
+func (t Table) ID(name string) (id uint16, ok bool) {
+	for id, n := range t {
+		if n == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// Chunk is a decoded chunk or keyframe.
+type Chunk struct {
+	Data []byte
+	// Table is optional, when set it is used to fill Packet.Name.
+	Table Table
+}
+
+// NewChunk wraps decoded chunk data.
+func NewChunk(data []byte, table Table) *Chunk {
+	return &Chunk{Data: data, Table: table}
+}
+
+// Packets iterates over every packet of the chunk in order.
+// Packets whose ID is not in the table are yielded raw with an empty Name.
+// Iteration stops after the first error, which is yielded with a zero Packet.
+func (c *Chunk) Packets() iter.Seq2[Packet, error] {
+	return func(yield func(Packet, error) bool) {
+		d := decoder{data: c.Data}
+		var prev Packet
+
+		for d.pos < len(d.data) {
+			p, err := d.next(prev)
+			if err != nil {
+				yield(Packet{}, err)
+				return
+			}
+			if c.Table != nil {
+				p.Name, _ = c.Table.Name(p.ID)
+			}
+			if !yield(p, nil) {
+				return
+			}
+			prev = p
+		}
+	}
+}
+
+// All collects every packet of the chunk, stopping at the first error.
+func (c *Chunk) All() ([]Packet, error) {
+	var out []Packet
+	for p, err := range c.Packets() {
+		if err != nil {
+			return out, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) next(prev Packet) (Packet, error) {
+	start := d.pos
+
+	marker, err := d.u8()
+	if err != nil {
+		return Packet{}, err
+	}
+	p := Packet{Channel: marker & channelMask}
+
+	if marker&flagRelativeTime != 0 {
+		delta, err := d.u8()
+		if err != nil {
+			return Packet{}, d.truncated(start, err)
+		}
+		p.Time = prev.Time + time.Duration(delta)*time.Millisecond
+	} else {
+		bits, err := d.u32()
+		if err != nil {
+			return Packet{}, d.truncated(start, err)
+		}
+		seconds := math.Float32frombits(bits)
+		if math.IsNaN(float64(seconds)) || seconds < 0 {
+			return Packet{}, fmt.Errorf("packet at offset %d has invalid time %v", start, seconds)
+		}
+		p.Time = time.Duration(float64(seconds) * float64(time.Second))
+	}
+
+	var length uint32
+	if marker&flagShortLength != 0 {
+		l, err := d.u8()
+		if err != nil {
+			return Packet{}, d.truncated(start, err)
+		}
+		length = uint32(l)
+	} else if length, err = d.u32(); err != nil {
+		return Packet{}, d.truncated(start, err)
+	}
+
+	if marker&flagSameID != 0 {
+		p.ID = prev.ID
+	} else if p.ID, err = d.u16(); err != nil {
+		return Packet{}, d.truncated(start, err)
+	}
+
+	if marker&flagRelativeNetID != 0 {
+		delta, err := d.u8()
+		if err != nil {
+			return Packet{}, d.truncated(start, err)
+		}
+		p.NetID = prev.NetID + uint32(delta)
+	} else if p.NetID, err = d.u32(); err != nil {
+		return Packet{}, d.truncated(start, err)
+	}
+
+	if uint64(d.pos)+uint64(length) > uint64(len(d.data)) {
+		return Packet{}, fmt.Errorf("packet at offset %d: payload of %d bytes overflows the chunk", start, length)
+	}
+	p.Payload = d.data[d.pos : d.pos+int(length)]
+	d.pos += int(length)
+
+	return p, nil
+}
+
+func (d *decoder) truncated(start int, err error) error {
+	return fmt.Errorf("packet at offset %d: %w", start, err)
+}
+
+var errShort = errors.New("unexpected end of chunk")
+
+func (d *decoder) u8() (uint8, error) {
+	if d.pos+1 > len(d.data) {
+		return 0, errShort
+	}
+	v := d.data[d.pos]
+	d.pos++
+	return v, nil
+}
+
+func (d *decoder) u16() (uint16, error) {
+	if d.pos+2 > len(d.data) {
+		return 0, errShort
+	}
+	v := binary.LittleEndian.Uint16(d.data[d.pos:])
+	d.pos += 2
+	return v, nil
+}
+
+func (d *decoder) u32() (uint32, error) {
+	if d.pos+4 > len(d.data) {
+		return 0, errShort
+	}
+	v := binary.LittleEndian.Uint32(d.data[d.pos:])
+	d.pos += 4
+	return v, nil
+}
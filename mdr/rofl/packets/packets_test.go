@@ -0,0 +1,114 @@
+package packets
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fullBlock encodes a packet with every field stored in full.
+func fullBlock(channel uint8, seconds float32, id uint16, netID uint32, payload string) []byte {
+	le := binary.LittleEndian
+	b := []byte{channel}
+	b = le.AppendUint32(b, math.Float32bits(seconds))
+	b = le.AppendUint32(b, uint32(len(payload)))
+	b = le.AppendUint16(b, id)
+	b = le.AppendUint32(b, netID)
+	return append(b, payload...)
+}
+
+// shortBlock encodes a packet with every field compressed against the previous one.
+func shortBlock(channel, deltaMs, deltaNetID uint8, payload string) []byte {
+	marker := flagRelativeTime | flagSameID | flagRelativeNetID | flagShortLength | channel
+	b := []byte{marker, deltaMs, uint8(len(payload)), deltaNetID}
+	return append(b, payload...)
+}
+
+func TestPackets(t *testing.T) {
+	first := Packet{Time: 1500 * time.Millisecond, Channel: 3, ID: 0x1234, NetID: 0x40000001, Payload: []byte("ab")}
+	second := Packet{Time: 1510 * time.Millisecond, Channel: 1, ID: 0x1234, NetID: 0x40000003, Payload: []byte("c")}
+
+	negative := fullBlock(0, -1, 1, 1, "")
+	nan := fullBlock(0, float32(math.NaN()), 1, 1, "")
+	overflow := fullBlock(0, 1, 1, 1, "ab")
+	binary.LittleEndian.PutUint32(overflow[5:], 10)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		table     Table
+		want      []Packet
+		wantErr   bool
+		wantShort bool
+	}{
+		{name: "empty", data: nil},
+		{name: "full block", data: fullBlock(3, 1.5, 0x1234, 0x40000001, "ab"), want: []Packet{first}},
+		{
+			name: "compressed block",
+			data: slices.Concat(fullBlock(3, 1.5, 0x1234, 0x40000001, "ab"), shortBlock(1, 10, 2, "c")),
+			want: []Packet{first, second},
+		},
+		{
+			name:  "named by the table",
+			data:  fullBlock(3, 1.5, 0x1234, 0x40000001, "ab"),
+			table: Table{0x1234: "Movement"},
+			want:  []Packet{{Time: first.Time, Channel: 3, ID: 0x1234, Name: "Movement", NetID: first.NetID, Payload: first.Payload}},
+		},
+		{
+			name: "empty payload",
+			data: fullBlock(0, 0, 7, 9, ""),
+			want: []Packet{{ID: 7, NetID: 9, Payload: []byte{}}},
+		},
+		{name: "marker only", data: []byte{0x00}, wantErr: true, wantShort: true},
+		{name: "cut in the time", data: fullBlock(3, 1.5, 1, 1, "ab")[:3], wantErr: true, wantShort: true},
+		{name: "cut in the length", data: fullBlock(3, 1.5, 1, 1, "ab")[:7], wantErr: true, wantShort: true},
+		{name: "cut in the packet ID", data: fullBlock(3, 1.5, 1, 1, "ab")[:10], wantErr: true, wantShort: true},
+		{name: "cut in the net ID", data: fullBlock(3, 1.5, 1, 1, "ab")[:13], wantErr: true, wantShort: true},
+		{name: "cut in a compressed block", data: shortBlock(0, 1, 1, "c")[:3], wantErr: true, wantShort: true},
+		{name: "payload overflows the chunk", data: overflow, wantErr: true},
+		{name: "negative time", data: negative, wantErr: true},
+		{name: "NaN time", data: nan, wantErr: true},
+		{
+			name:    "error after a valid packet",
+			data:    slices.Concat(fullBlock(3, 1.5, 0x1234, 0x40000001, "ab"), []byte{0x00, 0x01}),
+			want:    []Packet{first},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewChunk(tt.data, tt.table).All()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("All() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantShort && !errors.Is(err, errShort) {
+				t.Errorf("All() error = %v, want %v", err, errShort)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("All() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPacketsStopEarly(t *testing.T) {
+	data := slices.Concat(fullBlock(0, 1, 1, 1, "a"), fullBlock(0, 2, 2, 2, "b"), fullBlock(0, 3, 3, 3, "c"))
+
+	n := 0
+	for p, err := range NewChunk(data, nil).Packets() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+		if p.ID == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("iterated over %d packets after breaking on the second, want 2", n)
+	}
+}
@@ -0,0 +1,269 @@
+package rofl
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Team is the side a participant plays on, using the values of the TEAM stat.
+type Team int
+
+const (
+	TeamUnknown Team = 0
+	TeamBlue    Team = 100
+	TeamRed     Team = 200
+)
+
+func (t Team) String() string {
+	switch t {
+	case TeamBlue:
+		return "blue"
+	case TeamRed:
+		return "red"
+	default:
+		return "unknown"
+	}
+}
+
+// Position is the role of a participant, as in the TEAM_POSITION and INDIVIDUAL_POSITION stats.
+type Position int
+
+const (
+	// PositionNone is used when the game has no positions, e.g. ARAM or Arena.
+	PositionNone Position = iota
+	PositionTop
+	PositionJungle
+	PositionMiddle
+	PositionBottom
+	PositionUtility
+)
+
+var positionNames = [...]string{
+	PositionNone:    "",
+	PositionTop:     "TOP",
+	PositionJungle:  "JUNGLE",
+	PositionMiddle:  "MIDDLE",
+	PositionBottom:  "BOTTOM",
+	PositionUtility: "UTILITY",
+}
+
+// ParsePosition parses a position as written in the metadata ("TOP", "UTILITY", ...).
+// An empty string or "Invalid", written when the game has no positions, gives PositionNone.
+func ParsePosition(s string) (Position, error) {
+	if s == "Invalid" {
+		return PositionNone, nil
+	}
+	for p, name := range positionNames {
+		if name == s {
+			return Position(p), nil
+		}
+	}
+	return PositionNone, fmt.Errorf("unknown position %q", s)
+}
+
+// String returns the position as written in the metadata.
+func (p Position) String() string {
+	if p < 0 || int(p) >= len(positionNames) {
+		return fmt.Sprintf("Position(%d)", int(p))
+	}
+	return positionNames[p]
+}
+
+// ItemID identifies an item. Zero is an empty slot.
+type ItemID int32
+
+// RuneID identifies a rune, a rune path (style) or a stat shard.
+type RuneID int32
+
+// SummonerSpellID identifies a summoner spell.
+type SummonerSpellID int32
+
+// Runes is the rune page of a participant.
+type Runes struct {
+	PrimaryStyle RuneID
+	SubStyle     RuneID
+	// Perks are the chosen runes: the keystone and three runes of the primary path,
+	// then the two runes of the secondary path.
+	Perks [6]RuneID
+	// StatPerks are the stat shards: offense, flex and defense.
+	StatPerks [3]RuneID
+}
+
+// Keystone returns the keystone rune, the first perk.
+func (r Runes) Keystone() RuneID {
+	return r.Perks[0]
+}
+
+// Participant is a typed view of the most used stats of a participant.
+// Stats points to the stats it was built from, for everything else.
+type Participant struct {
+	// ID is the participant ID, from 1 to the number of participants.
+	ID             int
+	PUUID          string
+	Name           string
+	RiotIDGameName string
+	RiotIDTagLine  string
+	// Champion is the internal name of the champion, e.g. "MonkeyKing" for Wukong.
+	Champion           string
+	Team               Team
+	TeamPosition       Position
+	IndividualPosition Position
+	Won                bool
+	Level              int
+	Kills              int
+	Deaths             int
+	Assists            int
+	TimePlayed         time.Duration
+	TimeSpentDead      time.Duration
+	// Items are the items held at the end of the game, slot 6 being the trinket.
+	Items          [7]ItemID
+	Runes          Runes
+	SummonerSpells [2]SummonerSpellID
+
+	Stats *StatsJSON
+}
+
+// ConversionError reports a stat that could not be converted to its Participant field.
+type ConversionError struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("stat %s=%q: %v", e.Key, e.Value, e.Err)
+}
+
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// Participant converts the stats into a Participant. Every stat that cannot be converted
+// is reported as a *ConversionError, joined in the returned error; the other fields are
+// still filled.
+func (s *StatsJSON) Participant() (Participant, error) {
+	c := converter{}
+	p := Participant{
+		ID:             c.nonNegative("ID", s.ID),
+		PUUID:          s.Puuid,
+		Name:           s.Name,
+		RiotIDGameName: s.RiotIDGameName,
+		RiotIDTagLine:  s.RiotIDTagLine.text(),
+		Champion:       s.Skin,
+		Level:          c.nonNegative("LEVEL", s.Level),
+		Kills:          c.nonNegative("CHAMPIONS_KILLED", s.ChampionsKilled),
+		Deaths:         c.nonNegative("NUM_DEATHS", s.NumDeaths),
+		Assists:        c.nonNegative("ASSISTS", s.Assists),
+		TimePlayed:     c.seconds("TIME_PLAYED", s.TimePlayed),
+		TimeSpentDead:  c.seconds("TOTAL_TIME_SPENT_DEAD", s.TotalTimeSpentDead),
+		Stats:          s,
+	}
+
+	switch Team(s.Team) {
+	case TeamBlue, TeamRed:
+		p.Team = Team(s.Team)
+	default:
+		c.fail("TEAM", strconv.FormatInt(int64(s.Team), 10), errors.New("unknown team"))
+	}
+
+	switch s.Win {
+	case "Win":
+		p.Won = true
+	case "Fail":
+	default:
+		c.fail("WIN", s.Win, errors.New(`neither "Win" nor "Fail"`))
+	}
+
+	p.TeamPosition = c.position("TEAM_POSITION", s.TeamPosition)
+	p.IndividualPosition = c.position("INDIVIDUAL_POSITION", s.IndividualPosition)
+
+	for i, v := range []FlexInt64{s.Item0, s.Item1, s.Item2, s.Item3, s.Item4, s.Item5, s.Item6} {
+		p.Items[i] = ItemID(c.id(fmt.Sprintf("ITEM%d", i), v))
+	}
+
+	p.Runes.PrimaryStyle = RuneID(c.id("PERK_PRIMARY_STYLE", s.PerkPrimaryStyle))
+	p.Runes.SubStyle = RuneID(c.id("PERK_SUB_STYLE", s.PerkSubStyle))
+	for i, v := range []FlexInt64{s.Perk0, s.Perk1, s.Perk2, s.Perk3, s.Perk4, s.Perk5} {
+		p.Runes.Perks[i] = RuneID(c.id(fmt.Sprintf("PERK%d", i), v))
+	}
+	for i, v := range []FlexInt64{s.StatPerk0, s.StatPerk1, s.StatPerk2} {
+		p.Runes.StatPerks[i] = RuneID(c.id(fmt.Sprintf("STAT_PERK_%d", i), v))
+	}
+
+	p.SummonerSpells[0] = SummonerSpellID(c.id("SUMMONER_SPELL_1", s.SummonerSpell1))
+	p.SummonerSpells[1] = SummonerSpellID(c.id("SUMMONER_SPELL_2", s.SummonerSpell2))
+
+	return p, errors.Join(c.errs...)
+}
+
+// Participants converts the stats of every participant, see StatsJSON.Participant.
+// The returned error joins the conversion errors of all of them.
+func (m *Metadata) Participants() ([]Participant, error) {
+	participants := make([]Participant, len(m.StatsJSON))
+	var errs []error
+	for i := range m.StatsJSON {
+		p, err := m.StatsJSON[i].Participant()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("participant %d: %w", i+1, err))
+		}
+		participants[i] = p
+	}
+	return participants, errors.Join(errs...)
+}
+
+func (t *RiotIDTagLine) text() string {
+	switch {
+	case t == nil:
+		return ""
+	case t.String != nil:
+		return *t.String
+	case t.Integer != nil:
+		return strconv.FormatInt(*t.Integer, 10)
+	default:
+		return ""
+	}
+}
+
+// converter collects the errors of the conversion of one participant.
+type converter struct {
+	errs []error
+}
+
+func (c *converter) fail(key, value string, err error) {
+	c.errs = append(c.errs, &ConversionError{Key: key, Value: value, Err: err})
+}
+
+func (c *converter) nonNegative(key string, v FlexInt64) int {
+	if int64(v) != int64(int(v)) || v < 0 {
+		c.fail(key, strconv.FormatInt(int64(v), 10), errors.New("out of range"))
+		return 0
+	}
+	return int(v)
+}
+
+// id converts a game data ID, which fits in an int32.
+func (c *converter) id(key string, v FlexInt64) int32 {
+	if v < 0 || v > 1<<31-1 {
+		c.fail(key, strconv.FormatInt(int64(v), 10), errors.New("not a valid ID"))
+		return 0
+	}
+	return int32(v)
+}
+
+func (c *converter) seconds(key string, v FlexInt64) time.Duration {
+	if v < 0 || int64(v) > int64(time.Duration(1<<63-1)/time.Second) {
+		c.fail(key, strconv.FormatInt(int64(v), 10), errors.New("not a valid duration"))
+		return 0
+	}
+	return time.Duration(v) * time.Second
+}
+
+func (c *converter) position(key, s string) Position {
+	p, err := ParsePosition(s)
+	if err != nil {
+		c.fail(key, s, err)
+	}
+	return p
+}
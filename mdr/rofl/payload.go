@@ -0,0 +1,93 @@
+package rofl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const payloadHeaderFixedLength = 34
+
+// PayloadHeader describes the replay payload: which game it belongs to,
+// how many chunks and keyframes it holds and the key they are encrypted with.
+//
+// Legacy files store it as a binary section right after the metadata:
+//
+//	offset  size  field
+//	0x00    8     game ID
+//	0x08    4     game length (ms)
+//	0x0C    4     keyframe count
+//	0x10    4     chunk count
+//	0x14    4     end of startup chunk ID
+//	0x18    4     start of game chunk ID
+//	0x1C    4     keyframe interval (ms)
+//	0x20    2     encryption key length
+//	0x22    n     encryption key (base64)
+//
+// Trailer files do not have this section anymore. For them the game ID and platform
+// come from the replay file name (PLATFORM-GAMEID.rofl) and the counts from the metadata.
+type PayloadHeader struct {
+	GameID            uint64
+	Platform          string
+	GameLength        uint32
+	KeyframeCount     uint32
+	ChunkCount        uint32
+	EndStartupChunkID uint32
+	StartGameChunkID  uint32
+	KeyframeInterval  uint32
+	EncryptionKey     string
+}
+
+// MatchID returns the match ID in the format used by the Riot API (e.g. EUW1_7610660427).
+// It returns an empty string when the platform is not known.
+func (p PayloadHeader) MatchID() string {
+	if p.Platform == "" || p.GameID == 0 {
+		return ""
+	}
+	return p.Platform + "_" + strconv.FormatUint(p.GameID, 10)
+}
+
+func parsePayloadHeader(data []byte) (PayloadHeader, error) {
+	if len(data) < payloadHeaderFixedLength {
+		return PayloadHeader{}, fmt.Errorf("%w: payload header too short: %d bytes", ErrCorrupted, len(data))
+	}
+
+	le := binary.LittleEndian
+	p := PayloadHeader{
+		GameID:            le.Uint64(data[0:]),
+		GameLength:        le.Uint32(data[8:]),
+		KeyframeCount:     le.Uint32(data[12:]),
+		ChunkCount:        le.Uint32(data[16:]),
+		EndStartupChunkID: le.Uint32(data[20:]),
+		StartGameChunkID:  le.Uint32(data[24:]),
+		KeyframeInterval:  le.Uint32(data[28:]),
+	}
+
+	keyLength := int(le.Uint16(data[32:]))
+	if payloadHeaderFixedLength+keyLength > len(data) {
+		return PayloadHeader{}, fmt.Errorf("%w: encryption key length %d overflows payload header", ErrCorrupted, keyLength)
+	}
+	p.EncryptionKey = string(data[payloadHeaderFixedLength : payloadHeaderFixedLength+keyLength])
+
+	return p, nil
+}
+
+// parseReplayName extracts the platform and game ID from a replay file name
+// such as "EUW1-7610660427.rofl". ok is false when the name does not follow that format.
+func parseReplayName(path string) (platform string, gameID uint64, ok bool) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	platform, id, found := strings.Cut(name, "-")
+	if !found || platform == "" {
+		return "", 0, false
+	}
+
+	gameID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return strings.ToUpper(platform), gameID, true
+}
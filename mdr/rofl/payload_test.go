@@ -0,0 +1,165 @@
+package rofl
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// payloadHeaderBytes encodes p as legacy files store it.
+func payloadHeaderBytes(p PayloadHeader) []byte {
+	le := binary.LittleEndian
+	buf := le.AppendUint64(nil, p.GameID)
+	for _, v := range []uint32{p.GameLength, p.KeyframeCount, p.ChunkCount, p.EndStartupChunkID, p.StartGameChunkID, p.KeyframeInterval} {
+		buf = le.AppendUint32(buf, v)
+	}
+	buf = le.AppendUint16(buf, uint16(len(p.EncryptionKey)))
+	return append(buf, p.EncryptionKey...)
+}
+
+func TestParsePayloadHeader(t *testing.T) {
+	full := PayloadHeader{
+		GameID:            7610660427,
+		GameLength:        1500000,
+		KeyframeCount:     25,
+		ChunkCount:        50,
+		EndStartupChunkID: 2,
+		StartGameChunkID:  3,
+		KeyframeInterval:  60000,
+		EncryptionKey:     "c2VjcmV0a2V5",
+	}
+	noKey := full
+	noKey.EncryptionKey = ""
+
+	// The key length announces more bytes than the section holds
+	overflow := payloadHeaderBytes(full)
+	binary.LittleEndian.PutUint16(overflow[32:], uint16(len(full.EncryptionKey)+1))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    PayloadHeader
+		wantErr error
+	}{
+		{name: "with key", data: payloadHeaderBytes(full), want: full},
+		{name: "without key", data: payloadHeaderBytes(noKey), want: noKey},
+		{name: "trailing bytes", data: append(payloadHeaderBytes(full), 0xFF, 0xFF), want: full},
+		{name: "empty", data: nil, wantErr: ErrCorrupted},
+		{name: "cut in the fixed part", data: payloadHeaderBytes(full)[:payloadHeaderFixedLength-1], wantErr: ErrCorrupted},
+		{name: "cut in the key", data: payloadHeaderBytes(full)[:payloadHeaderFixedLength+4], wantErr: ErrCorrupted},
+		{name: "key length overflow", data: overflow, wantErr: ErrCorrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePayloadHeader(tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parsePayloadHeader() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePayloadHeader() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parsePayloadHeader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReplayName(t *testing.T) {
+	tests := []struct {
+		path         string
+		wantPlatform string
+		wantGameID   uint64
+		wantOK       bool
+	}{
+		{path: "EUW1-7610660427.rofl", wantPlatform: "EUW1", wantGameID: 7610660427, wantOK: true},
+		{path: "/replays/na1-5555.rofl", wantPlatform: "NA1", wantGameID: 5555, wantOK: true},
+		{path: "EUW1-7610660427", wantPlatform: "EUW1", wantGameID: 7610660427, wantOK: true},
+		{path: "EUW1_7610660427.rofl"},
+		{path: "-7610660427.rofl"},
+		{path: "EUW1-76106x0427.rofl"},
+		{path: "EUW1-.rofl"},
+		{path: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			platform, gameID, ok := parseReplayName(tt.path)
+			if platform != tt.wantPlatform || gameID != tt.wantGameID || ok != tt.wantOK {
+				t.Errorf("parseReplayName(%q) = %q, %d, %v, want %q, %d, %v",
+					tt.path, platform, gameID, ok, tt.wantPlatform, tt.wantGameID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPayloadHeaderFromFile(t *testing.T) {
+	header := PayloadHeader{GameID: 42, GameLength: 1500000, KeyframeCount: 1, ChunkCount: 2, EncryptionKey: "a2V5"}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		path    string
+		want    PayloadHeader
+		wantErr error
+	}{
+		{
+			name: "legacy",
+			data: legacyFile([]byte(testMetadata), payloadHeaderBytes(header), nil),
+			path: "KR-7.rofl",
+			// The game ID of the payload header wins over the file name
+			want: PayloadHeader{GameID: 42, Platform: "KR", GameLength: 1500000, KeyframeCount: 1, ChunkCount: 2, EncryptionKey: "a2V5"},
+		},
+		{
+			name: "trailer",
+			data: trailerFile("15.23.726.9074", nil, []byte(testMetadata)),
+			path: "EUW1-7610660427.rofl",
+			want: PayloadHeader{GameID: 7610660427, Platform: "EUW1", GameLength: 1500000, KeyframeCount: 1, ChunkCount: 1},
+		},
+		{
+			name: "trailer without a replay name",
+			data: trailerFile("15.23.726.9074", nil, []byte(testMetadata)),
+			want: PayloadHeader{GameLength: 1500000, KeyframeCount: 1, ChunkCount: 1},
+		},
+		{
+			name:    "legacy with a short payload header",
+			data:    legacyFile([]byte(testMetadata), payloadHeaderBytes(header)[:10], nil),
+			wantErr: ErrCorrupted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseRofl(tt.data, WithName(tt.path))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseRofl() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRofl() error = %v", err)
+			}
+			if f.PayloadHeader != tt.want {
+				t.Errorf("PayloadHeader = %+v, want %+v", f.PayloadHeader, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchID(t *testing.T) {
+	tests := []struct {
+		header PayloadHeader
+		want   string
+	}{
+		{header: PayloadHeader{Platform: "EUW1", GameID: 7610660427}, want: "EUW1_7610660427"},
+		{header: PayloadHeader{GameID: 7610660427}},
+		{header: PayloadHeader{Platform: "EUW1"}},
+	}
+	for _, tt := range tests {
+		if got := tt.header.MatchID(); got != tt.want {
+			t.Errorf("%+v.MatchID() = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package rofl
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Stat returns the numeric stat called name, using the key as written in the replay
+// (e.g. "CHAMPIONS_KILLED" or "S3A2_PrismaticAug"). It works for keys StatsJSON has
+// no field for. ok is false when the participant has no such key or its value is not an integer.
+func (s *StatsJSON) Stat(name string) (v int64, ok bool) {
+	value, ok := s.lookup(name)
+	if !ok {
+		return 0, false
+	}
+
+	var f FlexInt64
+	if err := f.UnmarshalJSON(value); err != nil {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// StatString returns the stat called name as text, whatever its JSON type.
+// ok is false when the participant has no such key or its value is null.
+func (s *StatsJSON) StatString(name string) (v string, ok bool) {
+	value, ok := s.lookup(name)
+	if !ok {
+		return "", false
+	}
+
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		return str, true
+	}
+	return string(value), true
+}
+
+// lookup returns the JSON value of the stat called name. Participants decoded from a
+// replay read it from the keys found in the replay, others, e.g. built as a struct
+// literal, from the field decoding the key. ok is false for missing keys and nulls.
+func (s *StatsJSON) lookup(name string) (value json.RawMessage, ok bool) {
+	if s.raw != nil {
+		value, ok = s.raw[name]
+	} else if f, known := statFields().fields[name]; known {
+		value, ok = f.marshal(s)
+	}
+	if !ok || string(value) == "null" {
+		return nil, false
+	}
+	return value, true
+}
+
+// syncRaw updates the raw value of the fields that changed since before, so that Stat
+// and StatString agree with fields patched after decoding, e.g. by FixMetadata.
+func (s *StatsJSON) syncRaw(before *StatsJSON) {
+	if s.raw == nil {
+		return
+	}
+	v, prev := reflect.ValueOf(s).Elem(), reflect.ValueOf(before).Elem()
+	for _, key := range statFields().keys {
+		f := statFields().fields[key]
+		if v.Field(f.index).Equal(prev.Field(f.index)) {
+			continue
+		}
+		if value, ok := f.marshal(s); ok {
+			s.raw[key] = value
+		} else {
+			delete(s.raw, key)
+		}
+	}
+}
+
+// StatValue returns the stat called name typed the way StatsJSON reads it: an int64 for
+// the FlexInt64 fields, a float64 for the FlexFloat64 ones and a string for the others.
+// Keys StatsJSON has no field for are typed after their value, and values a field can
+// not hold are returned as strings. ok is false when the participant has no such key
+// or its value is null.
+func (s *StatsJSON) StatValue(name string) (v any, ok bool) {
+	text, ok := s.StatString(name)
+	if !ok {
+		return nil, false
+	}
+
+	f, known := statFields().fields[name]
+	numeric := !known || f.typ == reflect.TypeFor[FlexInt64]() || f.typ == reflect.TypeFor[FlexFloat64]()
+	if !numeric {
+		return text, true
+	}
+	if !known || f.typ == reflect.TypeFor[FlexInt64]() {
+		if i, ok := s.Stat(name); ok {
+			return i, true
+		}
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && text != "" {
+		return f, true
+	}
+	return text, true
+}
+
+// MarshalJSON encodes the fields of the participant followed by the keys StatsJSON
+// has no field for, so that re-encoding decoded metadata does not lose them.
+func (s StatsJSON) MarshalJSON() ([]byte, error) {
+	type fields StatsJSON
+	b, err := json.Marshal(fields(s))
+	if err != nil {
+		return nil, err
+	}
+
+	unknown := s.UnknownKeys()
+	if len(unknown) == 0 {
+		return b, nil
+	}
+	b = b[:len(b)-1]
+	for _, key := range unknown {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, ',')
+		b = append(b, name...)
+		b = append(b, ':')
+		b = append(b, s.raw[key]...)
+	}
+	return append(b, '}'), nil
+}
+
+// UnknownKeys returns, sorted, the keys of the participant that StatsJSON has no field for.
+// They are usually stats added by a patch newer than the struct and can be read with Stat.
+func (s *StatsJSON) UnknownKeys() []string {
+	known := statFields().fields
+
+	var keys []string
+	for key := range s.raw {
+		if _, ok := known[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// StatKeys returns the JSON keys StatsJSON has a field for, in the order of the fields.
+func StatKeys() []string {
+	return slices.Clone(statFields().keys)
+}
+
+// statFieldSet describes the StatsJSON fields decoding a stat key.
+type statFieldSet struct {
+	keys   []string // in the order of the fields
+	fields map[string]statField
+}
+
+type statField struct {
+	index int
+	typ   reflect.Type
+}
+
+// marshal returns the JSON value of the field in s.
+func (f statField) marshal(s *StatsJSON) (json.RawMessage, bool) {
+	b, err := json.Marshal(reflect.ValueOf(s).Elem().Field(f.index).Interface())
+	return b, err == nil
+}
+
+var statFields = sync.OnceValue(func() statFieldSet {
+	t := reflect.TypeFor[StatsJSON]()
+	set := statFieldSet{
+		keys:   make([]string, 0, t.NumField()),
+		fields: make(map[string]statField, t.NumField()),
+	}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			set.keys = append(set.keys, name)
+			set.fields[name] = statField{index: i, typ: f.Type}
+		}
+	}
+	return set
+})
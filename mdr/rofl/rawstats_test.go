@@ -0,0 +1,137 @@
+package rofl
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// statsMetadata returns metadata JSON whose statsJson holds the given participants,
+// each a JSON object.
+func statsMetadata(t *testing.T, participants ...string) []byte {
+	t.Helper()
+	stats, err := json.Marshal("[" + strings.Join(participants, ",") + "]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(`{"gameLength":1500000,"lastGameChunkId":1,"lastKeyFrameId":1,"statsJson":` + string(stats) + `}`)
+}
+
+// decodeStats decodes the participants the way replays store them.
+func decodeStats(t *testing.T, participants ...string) []StatsJSON {
+	t.Helper()
+	var m Metadata
+	if err := json.Unmarshal(statsMetadata(t, participants...), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m.StatsJSON
+}
+
+func TestStat(t *testing.T) {
+	decoded := decodeStats(t, `{"CHAMPIONS_KILLED":"7","WIN":"Win","NEXT_PATCH_COUNT":"3","NEXT_PATCH_EMPTY":null,"NEXT_PATCH_NAME":"Sylas"}`)[0]
+	literal := StatsJSON{ChampionsKilled: 4, Win: "Fail"}
+
+	tests := []struct {
+		name       string
+		stats      StatsJSON
+		key        string
+		wantInt    int64
+		wantIntOK  bool
+		wantText   string
+		wantTextOK bool
+		wantValue  any
+	}{
+		{name: "known integer", stats: decoded, key: "CHAMPIONS_KILLED", wantInt: 7, wantIntOK: true, wantText: "7", wantTextOK: true, wantValue: int64(7)},
+		{name: "known string", stats: decoded, key: "WIN", wantText: "Win", wantTextOK: true, wantValue: "Win"},
+		{name: "unknown integer", stats: decoded, key: "NEXT_PATCH_COUNT", wantInt: 3, wantIntOK: true, wantText: "3", wantTextOK: true, wantValue: int64(3)},
+		{name: "unknown string", stats: decoded, key: "NEXT_PATCH_NAME", wantText: "Sylas", wantTextOK: true, wantValue: "Sylas"},
+		{name: "null", stats: decoded, key: "NEXT_PATCH_EMPTY"},
+		{name: "missing", stats: decoded, key: "NEXT_PATCH_MISSING"},
+		{name: "struct literal integer", stats: literal, key: "CHAMPIONS_KILLED", wantInt: 4, wantIntOK: true, wantText: "4", wantTextOK: true, wantValue: int64(4)},
+		{name: "struct literal string", stats: literal, key: "WIN", wantText: "Fail", wantTextOK: true, wantValue: "Fail"},
+		{name: "struct literal unknown key", stats: literal, key: "NEXT_PATCH_COUNT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := tt.stats.Stat(tt.key); got != tt.wantInt || ok != tt.wantIntOK {
+				t.Errorf("Stat(%q) = %d, %v, want %d, %v", tt.key, got, ok, tt.wantInt, tt.wantIntOK)
+			}
+			if got, ok := tt.stats.StatString(tt.key); got != tt.wantText || ok != tt.wantTextOK {
+				t.Errorf("StatString(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.wantText, tt.wantTextOK)
+			}
+			if got, ok := tt.stats.StatValue(tt.key); got != tt.wantValue || ok != tt.wantTextOK {
+				t.Errorf("StatValue(%q) = %#v, %v, want %#v, %v", tt.key, got, ok, tt.wantValue, tt.wantTextOK)
+			}
+		})
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats StatsJSON
+		want  []string
+	}{
+		{
+			name:  "sorted",
+			stats: decodeStats(t, `{"CHAMPIONS_KILLED":"7","NEXT_PATCH_Z":"1","NEXT_PATCH_A":null}`)[0],
+			want:  []string{"NEXT_PATCH_A", "NEXT_PATCH_Z"},
+		},
+		{name: "none", stats: decodeStats(t, `{"CHAMPIONS_KILLED":"7"}`)[0]},
+		{name: "struct literal", stats: StatsJSON{ChampionsKilled: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.UnknownKeys(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnknownKeys() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatsJSONMarshalRoundTrip(t *testing.T) {
+	stats := decodeStats(t, `{"CHAMPIONS_KILLED":"7","NEXT_PATCH_COUNT":"3","NEXT_PATCH_EMPTY":null}`)[0]
+
+	b, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(b, &keys); err != nil {
+		t.Fatalf("MarshalJSON() = %s, not a JSON object: %v", b, err)
+	}
+	for key, want := range map[string]string{"CHAMPIONS_KILLED": "7", "NEXT_PATCH_COUNT": `"3"`, "NEXT_PATCH_EMPTY": "null"} {
+		if got, ok := keys[key]; !ok || string(got) != want {
+			t.Errorf("MarshalJSON() has %s = %s, want %s", key, got, want)
+		}
+	}
+
+	// Decoding the output again gives back the same stats, unknown keys included
+	again := decodeStats(t, string(b))[0]
+	if got, ok := again.Stat("NEXT_PATCH_COUNT"); !ok || got != 3 {
+		t.Errorf("Stat(%q) after a round trip = %d, %v, want 3, true", "NEXT_PATCH_COUNT", got, ok)
+	}
+	if again.ChampionsKilled != stats.ChampionsKilled || !reflect.DeepEqual(again.UnknownKeys(), stats.UnknownKeys()) {
+		t.Errorf("round trip gave %+v, want %+v", again, stats)
+	}
+}
+
+func TestStatAfterFixMetadata(t *testing.T) {
+	// Before Riot IDs, FixMetadata copies NAME into RIOT_ID_GAME_NAME
+	metadata := statsMetadata(t, `{"NAME":"Faker"}`, `{"NAME":"Old","RIOT_ID_GAME_NAME":"New"}`)
+	f, err := ParseRofl(trailerFile("13.1.489.123", nil, metadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{"Faker", "New"} {
+		p := &f.Metadata.StatsJSON[i]
+		if p.RiotIDGameName != want {
+			t.Errorf("participant %d RiotIDGameName = %q, want %q", i, p.RiotIDGameName, want)
+		}
+		if got, ok := p.StatString("RIOT_ID_GAME_NAME"); !ok || got != want {
+			t.Errorf("participant %d StatString(%q) = %q, %v, want %q, true", i, "RIOT_ID_GAME_NAME", got, ok, want)
+		}
+	}
+}
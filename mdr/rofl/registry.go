@@ -0,0 +1,81 @@
+package rofl
+
+import (
+	"slices"
+	"sync"
+)
+
+// VersionDecoder holds what changes in the metadata of a replay from one patch to another.
+//
+// A decoder applies from its Since version up to the next registered one, so a patch
+// that introduces a quirk needs a new decoder while the following patches keep using
+// it until something changes again.
+//
+// Packet ID tables are not part of the decoders: none has been verified against a real
+// replay yet, they will be added here once one is.
+type VersionDecoder struct {
+	// Since is the first version the decoder applies to.
+	Since GameVersion
+	// FixMetadata corrects the quirks of the metadata written by these patches.
+	// It is called once the metadata is decoded and may be nil.
+	FixMetadata func(*Metadata)
+}
+
+var registry struct {
+	sync.RWMutex
+	decoders []VersionDecoder // sorted by Since
+}
+
+// RegisterDecoder adds d to the decoders used by every replay parsed afterwards.
+// It replaces the decoder registered with the same Since version, if any.
+func RegisterDecoder(d VersionDecoder) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	i, found := slices.BinarySearchFunc(registry.decoders, d.Since, func(e VersionDecoder, v GameVersion) int {
+		return e.Since.Compare(v)
+	})
+	if found {
+		registry.decoders[i] = d
+		return
+	}
+	registry.decoders = slices.Insert(registry.decoders, i, d)
+}
+
+// DecoderFor returns the decoder for replays recorded with version v: the registered
+// decoder with the latest Since version not after v. Patches newer than every
+// registered decoder fall back to the latest one. ok is false when no decoder
+// applies, e.g. for an unknown version, in which case the zero decoder is returned.
+func DecoderFor(v GameVersion) (d VersionDecoder, ok bool) {
+	if v.IsZero() {
+		return VersionDecoder{}, false
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	for _, d := range slices.Backward(registry.decoders) {
+		if d.Since.Compare(v) <= 0 {
+			return d, true
+		}
+	}
+	return VersionDecoder{}, false
+}
+
+// riotIDRelease is the patch Riot IDs replaced summoner names in.
+var riotIDRelease = GameVersion{Major: 13, Minor: 21}
+
+func init() {
+	// Before Riot IDs, the player name only lives in NAME
+	RegisterDecoder(VersionDecoder{
+		Since: GameVersion{Major: 1},
+		FixMetadata: func(m *Metadata) {
+			for i := range m.StatsJSON {
+				if m.StatsJSON[i].RiotIDGameName == "" {
+					m.StatsJSON[i].RiotIDGameName = m.StatsJSON[i].Name
+				}
+			}
+		},
+	})
+	RegisterDecoder(VersionDecoder{Since: riotIDRelease})
+}
@@ -0,0 +1,138 @@
+package rofl
+
+import (
+	"slices"
+	"testing"
+)
+
+// useRegistry replaces the registered decoders with decoders for the rest of the test.
+func useRegistry(t *testing.T, decoders ...VersionDecoder) {
+	t.Helper()
+	registry.Lock()
+	saved := registry.decoders
+	registry.decoders = nil
+	registry.Unlock()
+	t.Cleanup(func() {
+		registry.Lock()
+		registry.decoders = saved
+		registry.Unlock()
+	})
+
+	for _, d := range decoders {
+		RegisterDecoder(d)
+	}
+}
+
+// tagged returns a decoder whose FixMetadata writes tag in Metadata.GameVersion,
+// to tell decoders apart.
+func tagged(since GameVersion, tag string) VersionDecoder {
+	return VersionDecoder{Since: since, FixMetadata: func(m *Metadata) { m.GameVersion = tag }}
+}
+
+// tag returns the tag of a decoder built by tagged.
+func tag(d VersionDecoder) string {
+	if d.FixMetadata == nil {
+		return ""
+	}
+	var m Metadata
+	d.FixMetadata(&m)
+	return m.GameVersion
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	v13, v14, v15 := GameVersion{Major: 13, Minor: 1}, GameVersion{Major: 14, Minor: 1}, GameVersion{Major: 15, Minor: 1}
+
+	// Registered out of order, kept sorted by Since
+	useRegistry(t, tagged(v15, "15"), tagged(v13, "13"), tagged(v14, "14"))
+	var since []GameVersion
+	for _, d := range registry.decoders {
+		since = append(since, d.Since)
+	}
+	if want := []GameVersion{v13, v14, v15}; !slices.Equal(since, want) {
+		t.Errorf("decoders since %v, want %v", since, want)
+	}
+
+	// The same Since version replaces the decoder
+	RegisterDecoder(tagged(v14, "14 again"))
+	if len(registry.decoders) != 3 {
+		t.Errorf("%d decoders after a replacement, want 3", len(registry.decoders))
+	}
+	if d, ok := DecoderFor(v14); !ok || tag(d) != "14 again" {
+		t.Errorf("DecoderFor(%v) = %q, %v, want the replacement", v14, tag(d), ok)
+	}
+}
+
+func TestDecoderFor(t *testing.T) {
+	useRegistry(t,
+		tagged(GameVersion{Major: 13, Minor: 21}, "13.21"),
+		tagged(GameVersion{Major: 14, Minor: 3, Build: 500}, "14.3.500"),
+		tagged(GameVersion{Major: 15, Minor: 1}, "15.1"),
+	)
+
+	tests := []struct {
+		version string
+		want    string
+		wantOK  bool
+	}{
+		{version: "13.21.1.1", want: "13.21", wantOK: true},
+		{version: "13.21", want: "13.21", wantOK: true},
+		{version: "14.3.499.9999", want: "13.21", wantOK: true},
+		{version: "14.3.500.0", want: "14.3.500", wantOK: true},
+		{version: "14.24.1.1", want: "14.3.500", wantOK: true},
+		{version: "15.1", want: "15.1", wantOK: true},
+		// Patches newer than every decoder use the latest one
+		{version: "25.1.1.1", want: "15.1", wantOK: true},
+		// Older than every decoder
+		{version: "13.20.9.9"},
+		{version: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			var v GameVersion
+			if tt.version != "" {
+				var err error
+				if v, err = ParseGameVersion(tt.version); err != nil {
+					t.Fatal(err)
+				}
+			}
+			d, ok := DecoderFor(v)
+			if tag(d) != tt.want || ok != tt.wantOK {
+				t.Errorf("DecoderFor(%v) = %q, %v, want %q, %v", v, tag(d), ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBuiltinDecoders(t *testing.T) {
+	metadata := func() *Metadata {
+		return &Metadata{StatsJSON: []StatsJSON{{Name: "Faker"}, {Name: "Old", RiotIDGameName: "New"}}}
+	}
+
+	tests := []struct {
+		version GameVersion
+		want    []string
+	}{
+		{version: GameVersion{Major: 13, Minor: 20, Build: 1, Revision: 1}, want: []string{"Faker", "New"}},
+		{version: GameVersion{Major: 4, Minor: 1}, want: []string{"Faker", "New"}},
+		// From Riot IDs on, RIOT_ID_GAME_NAME is left as the replay wrote it
+		{version: riotIDRelease, want: []string{"", "New"}},
+		{version: GameVersion{Major: 15, Minor: 23, Build: 726, Revision: 9074}, want: []string{"", "New"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version.String(), func(t *testing.T) {
+			d, ok := DecoderFor(tt.version)
+			if !ok {
+				t.Fatalf("DecoderFor(%v) found no decoder", tt.version)
+			}
+			m := metadata()
+			if d.FixMetadata != nil {
+				d.FixMetadata(m)
+			}
+			for i, want := range tt.want {
+				if got := m.StatsJSON[i].RiotIDGameName; got != want {
+					t.Errorf("participant %d RiotIDGameName = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
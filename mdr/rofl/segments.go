@@ -0,0 +1,250 @@
+package rofl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sort"
+)
+
+// SegmentType tells chunks and keyframes apart in the segment index.
+type SegmentType uint8
+
+const (
+	SegmentChunk    SegmentType = 1
+	SegmentKeyframe SegmentType = 2
+)
+
+func (t SegmentType) String() string {
+	switch t {
+	case SegmentChunk:
+		return "chunk"
+	case SegmentKeyframe:
+		return "keyframe"
+	default:
+		return fmt.Sprintf("SegmentType(%d)", uint8(t))
+	}
+}
+
+// segmentEntryLength is the size of one entry of the segment table:
+//
+//	offset  size  field
+//	0x00    4     segment ID
+//	0x04    1     segment type
+//	0x05    4     data length
+//	0x09    4     next chunk ID
+//	0x0D    4     data offset
+const segmentEntryLength = 17
+
+// Segment is one entry of the segment index.
+// Offset is the absolute position of the segment data in the file.
+type Segment struct {
+	ID          uint32
+	Type        SegmentType
+	Length      uint32
+	NextChunkID uint32
+	Offset      uint64
+}
+
+// SegmentIndex lists every chunk and keyframe stored in a replay, sorted by ID.
+type SegmentIndex struct {
+	Chunks    []Segment
+	Keyframes []Segment
+}
+
+// Chunk returns the chunk with the given ID.
+func (idx *SegmentIndex) Chunk(id uint32) (Segment, bool) {
+	return findSegment(idx.Chunks, id)
+}
+
+// Keyframe returns the keyframe with the given ID.
+func (idx *SegmentIndex) Keyframe(id uint32) (Segment, bool) {
+	return findSegment(idx.Keyframes, id)
+}
+
+func findSegment(segments []Segment, id uint32) (Segment, bool) {
+	i := sort.Search(len(segments), func(i int) bool { return segments[i].ID >= id })
+	if i < len(segments) && segments[i].ID == id {
+		return segments[i], true
+	}
+	return Segment{}, false
+}
+
+func (idx *SegmentIndex) add(s Segment) error {
+	switch s.Type {
+	case SegmentChunk:
+		idx.Chunks = append(idx.Chunks, s)
+	case SegmentKeyframe:
+		idx.Keyframes = append(idx.Keyframes, s)
+	default:
+		return fmt.Errorf("%w: segment %d has unknown type %d", ErrCorrupted, s.ID, s.Type)
+	}
+	return nil
+}
+
+func (idx *SegmentIndex) sort() {
+	sort.Slice(idx.Chunks, func(i, j int) bool { return idx.Chunks[i].ID < idx.Chunks[j].ID })
+	sort.Slice(idx.Keyframes, func(i, j int) bool { return idx.Keyframes[i].ID < idx.Keyframes[j].ID })
+}
+
+func decodeSegmentEntry(b []byte) Segment {
+	le := binary.LittleEndian
+	return Segment{
+		ID:          le.Uint32(b[0:]),
+		Type:        SegmentType(b[4]),
+		Length:      le.Uint32(b[5:]),
+		NextChunkID: le.Uint32(b[9:]),
+		Offset:      uint64(le.Uint32(b[13:])),
+	}
+}
+
+// buildSegmentIndex reads the segment table of a replay.
+//
+// Legacy files store the whole table at the payload offset, followed by the segment data;
+// entry offsets are relative to the end of the table. Trailer files store every entry
+// right before its data, from the end of the header up to the metadata.
+func buildSegmentIndex(r io.ReaderAt, size int64, header Header, payload PayloadHeader) (*SegmentIndex, error) {
+	idx := &SegmentIndex{}
+
+	switch header.Layout {
+	case LayoutLegacy:
+		count := int64(payload.ChunkCount) + int64(payload.KeyframeCount)
+		tableStart := int64(header.PayloadOffset)
+		dataStart := tableStart + count*segmentEntryLength
+		if dataStart > size {
+			err := fmt.Errorf("%w: segment table of %d entries overflows the file", ErrCorrupted, count)
+			return nil, parseError(StageSegmentIndex, tableStart, err)
+		}
+
+		table, err := readAt(r, tableStart, count*segmentEntryLength)
+		if err != nil {
+			return nil, parseError(StageSegmentIndex, tableStart, err)
+		}
+
+		entryOffset := tableStart
+		for entry := range slices.Chunk(table, segmentEntryLength) {
+			s := decodeSegmentEntry(entry)
+			s.Offset += uint64(dataStart)
+			if s.Offset+uint64(s.Length) > uint64(size) {
+				return nil, parseError(StageSegmentIndex, entryOffset, fmt.Errorf("%w: %s %d overflows the file", ErrCorrupted, s.Type, s.ID))
+			}
+			if err := idx.add(s); err != nil {
+				return nil, parseError(StageSegmentIndex, entryOffset, err)
+			}
+			entryOffset += segmentEntryLength
+		}
+	case LayoutTrailer:
+		if header.PayloadOffset == 0 {
+			return nil, parseError(StageSegmentIndex, -1, fmt.Errorf("%w: payload offset unknown for this file", ErrUnsupportedVersion))
+		}
+
+		pos := uint64(header.PayloadOffset)
+		end := uint64(header.MetadataOffset)
+		for pos < end {
+			if pos+segmentEntryLength > end {
+				return nil, parseError(StageSegmentIndex, int64(pos), fmt.Errorf("%w: segment entry crosses the metadata", ErrCorrupted))
+			}
+			entry, err := readAt(r, int64(pos), segmentEntryLength)
+			if err != nil {
+				return nil, parseError(StageSegmentIndex, int64(pos), err)
+			}
+			s := decodeSegmentEntry(entry)
+			s.Offset = pos + segmentEntryLength
+			if s.Offset+uint64(s.Length) > end {
+				return nil, parseError(StageSegmentIndex, int64(pos), fmt.Errorf("%w: %s %d overflows the payload", ErrCorrupted, s.Type, s.ID))
+			}
+			if err := idx.add(s); err != nil {
+				return nil, parseError(StageSegmentIndex, int64(pos), err)
+			}
+			pos = s.Offset + uint64(s.Length)
+		}
+	default:
+		return nil, parseError(StageSegmentIndex, -1, fmt.Errorf("%w: segment index unavailable for %s layout", ErrUnsupportedVersion, header.Layout))
+	}
+
+	idx.sort()
+	return idx, nil
+}
+
+// SegmentIndex returns the index of every chunk and keyframe in the replay.
+// It is built once, on first use, even when chunks are read concurrently.
+func (r *RoflFile) SegmentIndex() (*SegmentIndex, error) {
+	return r.segments()
+}
+
+func (r *RoflFile) indexSegments() (*SegmentIndex, error) {
+	idx, err := buildSegmentIndex(r.r, r.size, r.Header, r.PayloadHeader)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Debug("segment index built",
+		slog.Int("chunks", len(idx.Chunks)),
+		slog.Int("keyframes", len(idx.Keyframes)),
+	)
+	return idx, nil
+}
+
+// ReadChunk returns the raw, still encoded, bytes of the chunk with the given ID.
+func (r *RoflFile) ReadChunk(id uint32) ([]byte, error) {
+	idx, err := r.SegmentIndex()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := idx.Chunk(id)
+	if !ok {
+		return nil, fmt.Errorf("chunk %d: %w", id, ErrSegmentNotFound)
+	}
+	return r.readSegment(s)
+}
+
+// ReadKeyframe returns the raw, still encoded, bytes of the keyframe with the given ID.
+func (r *RoflFile) ReadKeyframe(id uint32) ([]byte, error) {
+	idx, err := r.SegmentIndex()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := idx.Keyframe(id)
+	if !ok {
+		return nil, fmt.Errorf("keyframe %d: %w", id, ErrSegmentNotFound)
+	}
+	return r.readSegment(s)
+}
+
+func (r *RoflFile) readSegment(s Segment) ([]byte, error) {
+	data, err := readAt(r.r, int64(s.Offset), int64(s.Length))
+	if err != nil {
+		return nil, parseError(StageSegment, int64(s.Offset), err)
+	}
+	return data, nil
+}
+
+// VerifySegments checks that the segment index matches what the metadata announces.
+func (r *RoflFile) VerifySegments() error {
+	idx, err := r.SegmentIndex()
+	if err != nil {
+		return err
+	}
+
+	if want := uint32(r.Metadata.LastGameChunkID); want != 0 {
+		if n := len(idx.Chunks); n == 0 || idx.Chunks[n-1].ID != want {
+			return parseError(StageSegmentIndex, -1, fmt.Errorf("%w: metadata announces last chunk %d, index has %d chunks", ErrCorrupted, want, n))
+		}
+	}
+	if want := uint32(r.Metadata.LastKeyFrameID); want != 0 {
+		if n := len(idx.Keyframes); n == 0 || idx.Keyframes[n-1].ID != want {
+			return parseError(StageSegmentIndex, -1, fmt.Errorf("%w: metadata announces last keyframe %d, index has %d keyframes", ErrCorrupted, want, n))
+		}
+	}
+	if r.Header.Layout == LayoutLegacy {
+		if uint32(len(idx.Chunks)) != r.PayloadHeader.ChunkCount {
+			return parseError(StageSegmentIndex, -1, fmt.Errorf("%w: payload header announces %d chunks, index has %d", ErrCorrupted, r.PayloadHeader.ChunkCount, len(idx.Chunks)))
+		}
+		if uint32(len(idx.Keyframes)) != r.PayloadHeader.KeyframeCount {
+			return parseError(StageSegmentIndex, -1, fmt.Errorf("%w: payload header announces %d keyframes, index has %d", ErrCorrupted, r.PayloadHeader.KeyframeCount, len(idx.Keyframes)))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package rofl
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// segmentEntry encodes one entry of the segment table.
+func segmentEntry(id uint32, t SegmentType, length, next, offset uint32) []byte {
+	le := binary.LittleEndian
+	b := le.AppendUint32(nil, id)
+	b = append(b, byte(t))
+	b = le.AppendUint32(b, length)
+	b = le.AppendUint32(b, next)
+	return le.AppendUint32(b, offset)
+}
+
+// legacySegment is a segment of a legacy file, its data offset relative to the end of the table.
+type legacySegment struct {
+	id     uint32
+	typ    SegmentType
+	offset uint32
+	data   string
+}
+
+// legacyPayload builds a legacy file whose payload holds the given segments,
+// with payload header counts as given.
+func legacyPayload(chunks, keyframes uint32, segments ...legacySegment) []byte {
+	var table, data []byte
+	for _, s := range segments {
+		table = append(table, segmentEntry(s.id, s.typ, uint32(len(s.data)), s.id+1, s.offset)...)
+		data = append(data, s.data...)
+	}
+	header := payloadHeaderBytes(PayloadHeader{ChunkCount: chunks, KeyframeCount: keyframes})
+	return legacyFile([]byte(testMetadata), header, slices.Concat(table, data))
+}
+
+// trailerSegment encodes a segment of a trailer file: its entry followed by its data.
+func trailerSegment(id uint32, t SegmentType, data string) []byte {
+	return append(segmentEntry(id, t, uint32(len(data)), id+1, 0), data...)
+}
+
+func TestSegmentIndex(t *testing.T) {
+	type segment struct {
+		id   uint32
+		data string
+	}
+	tests := []struct {
+		name          string
+		data          []byte
+		wantChunks    []segment
+		wantKeyframes []segment
+		wantErr       error
+	}{
+		{
+			name: "legacy",
+			data: legacyPayload(2, 1,
+				legacySegment{id: 2, typ: SegmentChunk, offset: 0, data: "chunk2"},
+				legacySegment{id: 1, typ: SegmentChunk, offset: 6, data: "c1"},
+				legacySegment{id: 1, typ: SegmentKeyframe, offset: 8, data: "kf1"},
+			),
+			wantChunks:    []segment{{1, "c1"}, {2, "chunk2"}},
+			wantKeyframes: []segment{{1, "kf1"}},
+		},
+		{
+			name:    "legacy table overflows the file",
+			data:    legacyPayload(1000, 0, legacySegment{id: 1, typ: SegmentChunk, data: "c1"}),
+			wantErr: ErrCorrupted,
+		},
+		{
+			name:    "legacy segment overflows the file",
+			data:    legacyPayload(1, 0, legacySegment{id: 1, typ: SegmentChunk, offset: 1, data: "c1"}),
+			wantErr: ErrCorrupted,
+		},
+		{
+			name:    "legacy segment offset out of bounds",
+			data:    legacyPayload(1, 0, legacySegment{id: 1, typ: SegmentChunk, offset: 0xFFFFFFF0, data: "c1"}),
+			wantErr: ErrCorrupted,
+		},
+		{
+			name:    "legacy unknown segment type",
+			data:    legacyPayload(1, 0, legacySegment{id: 1, typ: 7, data: "c1"}),
+			wantErr: ErrCorrupted,
+		},
+		{
+			name: "trailer",
+			data: trailerFile("15.23.726.9074", slices.Concat(
+				trailerSegment(1, SegmentChunk, "c1"),
+				trailerSegment(1, SegmentKeyframe, "kf1"),
+				trailerSegment(2, SegmentChunk, "chunk2"),
+			), []byte(testMetadata)),
+			wantChunks:    []segment{{1, "c1"}, {2, "chunk2"}},
+			wantKeyframes: []segment{{1, "kf1"}},
+		},
+		{
+			name:    "trailer entry crosses the metadata",
+			data:    trailerFile("15.23.726.9074", trailerSegment(1, SegmentChunk, "c1")[:10], []byte(testMetadata)),
+			wantErr: ErrCorrupted,
+		},
+		{
+			name:    "trailer segment overflows the payload",
+			data:    trailerFile("15.23.726.9074", trailerSegment(1, SegmentChunk, "c1")[:segmentEntryLength+1], []byte(testMetadata)),
+			wantErr: ErrCorrupted,
+		},
+		{
+			name:    "unknown layout",
+			data:    []byte("RIOTxx\x00\x00\x00\x00" + testMetadata),
+			wantErr: ErrUnsupportedVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseRofl(tt.data)
+			if err != nil {
+				t.Fatalf("ParseRofl() error = %v", err)
+			}
+
+			idx, err := f.SegmentIndex()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SegmentIndex() error = %v, want %v", err, tt.wantErr)
+				}
+				var perr *ParseError
+				if !errors.As(err, &perr) || perr.Stage != StageSegmentIndex {
+					t.Errorf("SegmentIndex() error = %#v, want a *ParseError of stage %q", err, StageSegmentIndex)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SegmentIndex() error = %v", err)
+			}
+
+			check := func(kind string, got []Segment, want []segment, read func(uint32) ([]byte, error)) {
+				if len(got) != len(want) {
+					t.Fatalf("%d %ss indexed, want %d", len(got), kind, len(want))
+				}
+				for i, w := range want {
+					if got[i].ID != w.id {
+						t.Errorf("%s %d has ID %d, want %d", kind, i, got[i].ID, w.id)
+					}
+					data, err := read(w.id)
+					if err != nil || string(data) != w.data {
+						t.Errorf("reading %s %d = %q, %v, want %q", kind, w.id, data, err, w.data)
+					}
+				}
+			}
+			check("chunk", idx.Chunks, tt.wantChunks, f.ReadChunk)
+			check("keyframe", idx.Keyframes, tt.wantKeyframes, f.ReadKeyframe)
+		})
+	}
+}
+
+func TestReadMissingSegment(t *testing.T) {
+	f, err := ParseRofl(trailerFile("15.23.726.9074", trailerSegment(1, SegmentChunk, "c1"), []byte(testMetadata)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.ReadChunk(2); !errors.Is(err, ErrSegmentNotFound) {
+		t.Errorf("ReadChunk(2) error = %v, want %v", err, ErrSegmentNotFound)
+	}
+	if _, err := f.ReadKeyframe(1); !errors.Is(err, ErrSegmentNotFound) {
+		t.Errorf("ReadKeyframe(1) error = %v, want %v", err, ErrSegmentNotFound)
+	}
+}
+
+func TestReadChunkConcurrently(t *testing.T) {
+	var payload []byte
+	for id := uint32(1); id <= 8; id++ {
+		payload = append(payload, trailerSegment(id, SegmentChunk, fmt.Sprint("chunk", id))...)
+	}
+	f, err := ParseRofl(trailerFile("15.23.726.9074", payload, []byte(testMetadata)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for id := uint32(1); id <= 8; id++ {
+		wg.Go(func() {
+			data, err := f.ReadChunk(id)
+			if want := fmt.Sprint("chunk", id); err != nil || string(data) != want {
+				t.Errorf("ReadChunk(%d) = %q, %v, want %q", id, data, err, want)
+			}
+		})
+	}
+	wg.Wait()
+}
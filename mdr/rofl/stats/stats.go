@@ -0,0 +1,115 @@
+// Package stats computes the usual derived metrics of each participant of a game
+// from the metadata of its replay: KDA, CS and gold per minute, damage share,
+// kill participation, vision per minute and damage per gold.
+//
+// Every ratio with a zero denominator is 0, except the KDA of a participant who never
+// died, which is kills + assists as in the client ("Perfect KDA"). Per minute metrics
+// are left at 0 for remakes, whose few minutes would give meaningless rates.
+package stats
+
+import (
+	"time"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+// RemakeThreshold is the game length under which a game that ended in an early
+// surrender is considered a remake.
+const RemakeThreshold = 5 * time.Minute
+
+// Metrics are the derived metrics of one participant.
+type Metrics struct {
+	// Participant indexes Metadata.StatsJSON.
+	Participant int
+	Team        rofl.Team
+
+	KDA float64
+	// Perfect is set when the participant never died, KDA is then kills + assists.
+	Perfect bool
+	// CS is the creep score: MINIONS_KILLED + NEUTRAL_MINIONS_KILLED.
+	CS                int64
+	CSPerMinute       float64
+	GoldPerMinute     float64
+	VisionPerMinute   float64
+	DamageShare       float64
+	KillParticipation float64
+	DamagePerGold     float64
+}
+
+// Game holds the metrics of every participant of a game.
+type Game struct {
+	Duration time.Duration
+	// Remake is set for games that ended in an early surrender before RemakeThreshold.
+	Remake       bool
+	Participants []Metrics
+}
+
+// Compute returns the metrics of every participant of the game described by m,
+// in the order of m.StatsJSON.
+func Compute(m *rofl.Metadata) Game {
+	g := Game{Duration: time.Duration(m.GameLength) * time.Millisecond}
+	g.Remake = isRemake(m, g.Duration)
+
+	// The WIN stats are not needed here, the teams are usable even when they disagree
+	teams, _ := m.Teams()
+	byTeam := make(map[rofl.Team]rofl.TeamStats, len(teams))
+	for _, t := range teams {
+		byTeam[t.Team] = t
+	}
+
+	minutes := g.Duration.Minutes()
+	if g.Remake {
+		minutes = 0
+	}
+
+	g.Participants = make([]Metrics, len(m.StatsJSON))
+	for i := range m.StatsJSON {
+		s := &m.StatsJSON[i]
+		team := byTeam[rofl.Team(s.Team)]
+
+		kills, deaths, assists := float64(s.ChampionsKilled), float64(s.NumDeaths), float64(s.Assists)
+		damage, gold := float64(s.TotalDamageDealtToChampions), float64(s.GoldEarned)
+
+		mt := Metrics{
+			Participant:       i,
+			Team:              rofl.Team(s.Team),
+			CS:                int64(s.MinionsKilled) + int64(s.NeutralMinionsKilled),
+			DamageShare:       ratio(damage, float64(team.DamageToChampions)),
+			KillParticipation: ratio(kills+assists, float64(team.Kills)),
+			DamagePerGold:     ratio(damage, gold),
+		}
+		if deaths == 0 {
+			mt.KDA = kills + assists
+			mt.Perfect = true
+		} else {
+			mt.KDA = (kills + assists) / deaths
+		}
+		mt.CSPerMinute = ratio(float64(mt.CS), minutes)
+		mt.GoldPerMinute = ratio(gold, minutes)
+		mt.VisionPerMinute = ratio(float64(s.VisionScore), minutes)
+
+		g.Participants[i] = mt
+	}
+
+	return g
+}
+
+func isRemake(m *rofl.Metadata, d time.Duration) bool {
+	if d >= RemakeThreshold {
+		return false
+	}
+	for i := range m.StatsJSON {
+		s := &m.StatsJSON[i]
+		if s.GameEndedInEarlySurrender != 0 || s.TeamEarlySurrendered != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
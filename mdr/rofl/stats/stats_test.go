@@ -0,0 +1,121 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+func TestCompute(t *testing.T) {
+	blue, red := rofl.FlexInt64(rofl.TeamBlue), rofl.FlexInt64(rofl.TeamRed)
+
+	tests := []struct {
+		name       string
+		length     time.Duration
+		stats      []rofl.StatsJSON
+		wantRemake bool
+		want       []Metrics
+	}{
+		{
+			name:   "regular game",
+			length: 30 * time.Minute,
+			stats: []rofl.StatsJSON{
+				{Team: blue, ChampionsKilled: 4, NumDeaths: 2, Assists: 6, MinionsKilled: 200, NeutralMinionsKilled: 40,
+					GoldEarned: 12000, TotalDamageDealtToChampions: 20000, VisionScore: 30},
+				{Team: blue, ChampionsKilled: 6, NumDeaths: 4, Assists: 2, MinionsKilled: 15,
+					GoldEarned: 9000, TotalDamageDealtToChampions: 5000, VisionScore: 60},
+			},
+			want: []Metrics{
+				{Participant: 0, Team: rofl.TeamBlue, KDA: 5, CS: 240, CSPerMinute: 8, GoldPerMinute: 400, VisionPerMinute: 1,
+					DamageShare: 0.8, KillParticipation: 1, DamagePerGold: 20000.0 / 12000},
+				{Participant: 1, Team: rofl.TeamBlue, KDA: 2, CS: 15, CSPerMinute: 0.5, GoldPerMinute: 300, VisionPerMinute: 2,
+					DamageShare: 0.2, KillParticipation: 0.8, DamagePerGold: 5000.0 / 9000},
+			},
+		},
+		{
+			name:   "perfect KDA",
+			length: 30 * time.Minute,
+			stats: []rofl.StatsJSON{
+				{Team: red, ChampionsKilled: 3, Assists: 4, GoldEarned: 6000, TotalDamageDealtToChampions: 3000},
+				{Team: red, ChampionsKilled: 1, NumDeaths: 2, Assists: 1, GoldEarned: 3000, TotalDamageDealtToChampions: 1000},
+			},
+			want: []Metrics{
+				{Participant: 0, Team: rofl.TeamRed, KDA: 7, Perfect: true, GoldPerMinute: 200,
+					DamageShare: 0.75, KillParticipation: 7.0 / 4, DamagePerGold: 0.5},
+				{Participant: 1, Team: rofl.TeamRed, KDA: 1, GoldPerMinute: 100,
+					DamageShare: 0.25, KillParticipation: 0.5, DamagePerGold: 1.0 / 3},
+			},
+		},
+		{
+			name:   "remake",
+			length: RemakeThreshold - time.Second,
+			stats: []rofl.StatsJSON{
+				{Team: blue, NumDeaths: 1, MinionsKilled: 12, GoldEarned: 600, TotalDamageDealtToChampions: 300,
+					VisionScore: 2, GameEndedInEarlySurrender: 1, TeamEarlySurrendered: 1},
+				{Team: red, ChampionsKilled: 1, MinionsKilled: 10, GoldEarned: 900, TotalDamageDealtToChampions: 450,
+					GameEndedInEarlySurrender: 1},
+			},
+			wantRemake: true,
+			// Per minute metrics are left out, the others are still computed
+			want: []Metrics{
+				{Participant: 0, Team: rofl.TeamBlue, CS: 12, DamageShare: 1, DamagePerGold: 0.5},
+				{Participant: 1, Team: rofl.TeamRed, KDA: 1, Perfect: true, CS: 10, DamageShare: 1, KillParticipation: 1, DamagePerGold: 0.5},
+			},
+		},
+		{
+			name:   "early surrender at the remake threshold",
+			length: RemakeThreshold,
+			stats: []rofl.StatsJSON{
+				{Team: blue, MinionsKilled: 50, GoldEarned: 1000, GameEndedInEarlySurrender: 1, TeamEarlySurrendered: 1},
+			},
+			want: []Metrics{
+				{Participant: 0, Team: rofl.TeamBlue, Perfect: true, CS: 50, CSPerMinute: 10, GoldPerMinute: 200},
+			},
+		},
+		{
+			name:   "short game without an early surrender",
+			length: 3 * time.Minute,
+			stats: []rofl.StatsJSON{
+				{Team: blue, ChampionsKilled: 2, NumDeaths: 1, MinionsKilled: 30, GoldEarned: 1500,
+					TotalDamageDealtToChampions: 900, VisionScore: 3},
+			},
+			want: []Metrics{
+				{Participant: 0, Team: rofl.TeamBlue, KDA: 2, CS: 30, CSPerMinute: 10, GoldPerMinute: 500, VisionPerMinute: 1,
+					DamageShare: 1, KillParticipation: 1, DamagePerGold: 0.6},
+			},
+		},
+		{
+			name:   "no team damage, kills or gold",
+			length: 20 * time.Minute,
+			stats: []rofl.StatsJSON{
+				{Team: blue, NumDeaths: 3},
+				{Team: blue, NumDeaths: 2},
+				{Team: red, ChampionsKilled: 5, GoldEarned: 2000, TotalDamageDealtToChampions: 800},
+			},
+			want: []Metrics{
+				{Participant: 0, Team: rofl.TeamBlue},
+				{Participant: 1, Team: rofl.TeamBlue},
+				{Participant: 2, Team: rofl.TeamRed, KDA: 5, Perfect: true, GoldPerMinute: 100,
+					DamageShare: 1, KillParticipation: 1, DamagePerGold: 0.4},
+			},
+		},
+		{name: "no participant", length: 30 * time.Minute, want: []Metrics{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &rofl.Metadata{GameLength: rofl.FlexInt64(tt.length.Milliseconds()), StatsJSON: tt.stats}
+			g := Compute(m)
+			if g.Duration != tt.length {
+				t.Errorf("Duration = %v, want %v", g.Duration, tt.length)
+			}
+			if g.Remake != tt.wantRemake {
+				t.Errorf("Remake = %v, want %v", g.Remake, tt.wantRemake)
+			}
+			if !reflect.DeepEqual(g.Participants, tt.want) {
+				t.Errorf("Participants = %+v, want %+v", g.Participants, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package rofl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// TeamStats aggregates the stats of the participants of one team.
+//
+// Objectives are summed from the per participant stats, which credit the participant who
+// landed the last hit: a turret destroyed by minions alone is not counted in Towers.
+type TeamStats struct {
+	Team Team
+	// Participants indexes Metadata.StatsJSON.
+	Participants []int
+	Won          bool
+
+	Kills             int64
+	Deaths            int64
+	Assists           int64
+	Gold              int64
+	DamageToChampions int64
+	VisionScore       int64
+
+	Towers     int64
+	Inhibitors int64
+	Dragons    int64
+	Barons     int64
+	Heralds    int64
+	Atakhans   int64
+	// Voidgrubs counts the HORDE_KILLS stat.
+	Voidgrubs int64
+	// Nexus is set when a participant of the team destroyed the enemy nexus.
+	Nexus bool
+
+	// EarlySurrendered is set when the team voted an early surrender (remake).
+	EarlySurrendered bool
+	// Surrendered is set when the game ended because the team surrendered.
+	Surrendered bool
+}
+
+// Teams aggregates the stats of every team, sorted by team (blue first).
+// The winner is taken from the WIN stats, which must agree within each team and
+// designate a single winning team; otherwise the teams are returned along with an error
+// wrapping ErrInconsistentWin.
+func (m *Metadata) Teams() ([]TeamStats, error) {
+	byTeam := map[Team]*TeamStats{}
+	disagree := map[Team]bool{}
+	var order []Team
+	var errs []error
+
+	for i := range m.StatsJSON {
+		s := &m.StatsJSON[i]
+		team := Team(s.Team)
+
+		t, ok := byTeam[team]
+		if !ok {
+			t = &TeamStats{Team: team, Won: s.Win == "Win"}
+			byTeam[team] = t
+			order = append(order, team)
+		} else if t.Won != (s.Win == "Win") && !disagree[team] {
+			disagree[team] = true
+			errs = append(errs, fmt.Errorf("%w: participants of the %s team disagree", ErrInconsistentWin, team))
+		}
+
+		t.Participants = append(t.Participants, i)
+		t.Kills += int64(s.ChampionsKilled)
+		t.Deaths += int64(s.NumDeaths)
+		t.Assists += int64(s.Assists)
+		t.Gold += int64(s.GoldEarned)
+		t.DamageToChampions += int64(s.TotalDamageDealtToChampions)
+		t.VisionScore += int64(s.VisionScore)
+		t.Towers += int64(s.TurretsKilled)
+		t.Inhibitors += int64(s.BarracksKilled)
+		t.Dragons += int64(s.DragonKills)
+		t.Barons += int64(s.BaronKills)
+		t.Heralds += int64(s.RiftHeraldKills)
+		t.Atakhans += int64(s.AtakhanKills)
+		t.Voidgrubs += int64(s.HordeKills)
+		t.Nexus = t.Nexus || s.HqKilled != 0
+		t.EarlySurrendered = t.EarlySurrendered || s.TeamEarlySurrendered != 0
+		t.Surrendered = t.Surrendered || (s.GameEndedInSurrender != 0 && s.Win != "Win")
+	}
+
+	slices.Sort(order)
+	teams := make([]TeamStats, len(order))
+	winners := 0
+	for i, team := range order {
+		teams[i] = *byTeam[team]
+		if teams[i].Won {
+			winners++
+		}
+	}
+	if len(teams) > 0 && winners != 1 {
+		errs = append(errs, fmt.Errorf("%w: %d winning teams", ErrInconsistentWin, winners))
+	}
+
+	return teams, errors.Join(errs...)
+}
+
+// Winner returns the team that won the game, see Teams.
+func (m *Metadata) Winner() (Team, error) {
+	teams, err := m.Teams()
+	if err != nil {
+		return TeamUnknown, err
+	}
+	for _, t := range teams {
+		if t.Won {
+			return t.Team, nil
+		}
+	}
+	return TeamUnknown, fmt.Errorf("%w: no participant", ErrInconsistentWin)
+}
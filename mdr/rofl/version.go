@@ -0,0 +1,78 @@
+package rofl
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GameVersion is the League of Legends client version a replay was recorded with,
+// e.g. 15.23.726.9074. Major and Minor make up the patch (15.23).
+type GameVersion struct {
+	Major    int
+	Minor    int
+	Build    int
+	Revision int
+}
+
+// ParseGameVersion parses a version such as "15.23.726.9074". The build and revision
+// are optional, so "15.23" is accepted as well.
+func ParseGameVersion(s string) (GameVersion, error) {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) < 2 || len(parts) > 4 {
+		return GameVersion{}, fmt.Errorf("invalid game version %q", s)
+	}
+
+	var nums [4]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return GameVersion{}, fmt.Errorf("invalid game version %q", s)
+		}
+		nums[i] = n
+	}
+
+	return GameVersion{Major: nums[0], Minor: nums[1], Build: nums[2], Revision: nums[3]}, nil
+}
+
+// IsZero reports whether the version is unknown.
+func (v GameVersion) IsZero() bool {
+	return v == GameVersion{}
+}
+
+// Patch returns the patch the version belongs to, e.g. "15.23".
+func (v GameVersion) Patch() string {
+	return strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor)
+}
+
+func (v GameVersion) String() string {
+	if v.IsZero() {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Build, v.Revision)
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is older, equal or newer than o.
+func (v GameVersion) Compare(o GameVersion) int {
+	return cmp.Or(
+		cmp.Compare(v.Major, o.Major),
+		cmp.Compare(v.Minor, o.Minor),
+		cmp.Compare(v.Build, o.Build),
+		cmp.Compare(v.Revision, o.Revision),
+	)
+}
+
+// detectGameVersion returns the version stored in the header, or in the metadata for
+// legacy files. It returns the zero version when neither holds a valid one.
+func detectGameVersion(header Header, metadata Metadata) GameVersion {
+	for _, s := range []string{header.Version, metadata.GameVersion} {
+		if s == "" {
+			continue
+		}
+		if v, err := ParseGameVersion(s); err == nil {
+			return v
+		}
+	}
+	return GameVersion{}
+}
@@ -0,0 +1,79 @@
+package rofl
+
+import "testing"
+
+func TestParseGameVersion(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    GameVersion
+		wantErr bool
+	}{
+		{s: "15.23.726.9074", want: GameVersion{Major: 15, Minor: 23, Build: 726, Revision: 9074}},
+		{s: "15.23.726", want: GameVersion{Major: 15, Minor: 23, Build: 726}},
+		{s: "15.23", want: GameVersion{Major: 15, Minor: 23}},
+		{s: " 13.1.489.123\n", want: GameVersion{Major: 13, Minor: 1, Build: 489, Revision: 123}},
+		{s: "0.0", want: GameVersion{}},
+		{s: "", wantErr: true},
+		{s: "15", wantErr: true},
+		{s: "15.23.726.9074.1", wantErr: true},
+		{s: "15.x", wantErr: true},
+		{s: "15..1", wantErr: true},
+		{s: "15.-1", wantErr: true},
+		{s: "Version 15.23", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseGameVersion(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGameVersion(%q) error = %v, want error %v", tt.s, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseGameVersion(%q) = %+v, want %+v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectGameVersion(t *testing.T) {
+	v15 := GameVersion{Major: 15, Minor: 23, Build: 726, Revision: 9074}
+	v13 := GameVersion{Major: 13, Minor: 1, Build: 489, Revision: 123}
+
+	tests := []struct {
+		name     string
+		header   string
+		metadata string
+		want     GameVersion
+	}{
+		{name: "header", header: "15.23.726.9074", want: v15},
+		{name: "metadata of legacy files", metadata: "13.1.489.123", want: v13},
+		{name: "header wins", header: "15.23.726.9074", metadata: "13.1.489.123", want: v15},
+		{name: "invalid header", header: "garbage", metadata: "13.1.489.123", want: v13},
+		{name: "both invalid", header: "garbage", metadata: "13"},
+		{name: "neither"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectGameVersion(Header{Version: tt.header}, Metadata{GameVersion: tt.metadata})
+			if got != tt.want {
+				t.Errorf("detectGameVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGameVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b GameVersion
+		want int
+	}{
+		{a: GameVersion{Major: 15, Minor: 23}, b: GameVersion{Major: 15, Minor: 23}, want: 0},
+		{a: GameVersion{Major: 14, Minor: 24}, b: GameVersion{Major: 15, Minor: 1}, want: -1},
+		{a: GameVersion{Major: 15, Minor: 10}, b: GameVersion{Major: 15, Minor: 9}, want: 1},
+		{a: GameVersion{Major: 15, Minor: 1, Build: 1, Revision: 2}, b: GameVersion{Major: 15, Minor: 1, Build: 1, Revision: 10}, want: -1},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Compare(tt.b); got != tt.want {
+			t.Errorf("%v.Compare(%v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
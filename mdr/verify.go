@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ZiedYousfi/analolzer/mdr/rofl"
+)
+
+type verifyResult struct {
+	Path      string `json:"path"`
+	OK        bool   `json:"ok"`
+	Chunks    int    `json:"chunks"`
+	Keyframes int    `json:"keyframes"`
+	Error     string `json:"error,omitempty"`
+}
+
+func runVerify(args []string) int {
+	c := newCLI("verify", "<file>...", formatText, formatJSON)
+	if code, ok := c.parse(args, 1); !ok {
+		return code
+	}
+
+	results := make([]verifyResult, len(c.args))
+	code := exitOK
+	for i, path := range c.args {
+		results[i] = verify(path, c.options())
+		if !results[i].OK {
+			code = exitFail
+		}
+	}
+
+	err := c.write(func(w io.Writer) error {
+		if c.format == formatJSON {
+			return writeJSON(w, results)
+		}
+		for _, r := range results {
+			if r.OK {
+				fmt.Fprintf(w, "%s: ok, %d chunks, %d keyframes\n", r.Path, r.Chunks, r.Keyframes)
+			} else {
+				fmt.Fprintf(w, "%s: FAIL: %s\n", r.Path, r.Error)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.fail(err)
+	}
+	return code
+}
+
+// verify parses the replay at path, checks its segment index and decodes every segment.
+func verify(path string, opts []rofl.Option) verifyResult {
+	res := verifyResult{Path: path}
+	fail := func(err error) verifyResult {
+		res.Error = err.Error()
+		return res
+	}
+
+	f, err := rofl.OpenRoflFile(path, opts...)
+	if err != nil {
+		return fail(err)
+	}
+	if err := f.VerifySegments(); err != nil {
+		return fail(err)
+	}
+	idx, err := f.SegmentIndex()
+	if err != nil {
+		return fail(err)
+	}
+	for _, s := range idx.Chunks {
+		if _, err := f.DecodeChunk(s.ID); err != nil {
+			return fail(err)
+		}
+		res.Chunks++
+	}
+	for _, s := range idx.Keyframes {
+		if _, err := f.DecodeKeyframe(s.ID); err != nil {
+			return fail(err)
+		}
+		res.Keyframes++
+	}
+
+	res.OK = true
+	return res
+}